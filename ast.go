@@ -1,10 +1,12 @@
-package main
+package trex
+
+import "regexp"
 
 type Node interface {
 	getPosition() Position
 	toString() string
 	getChildren() []Node
-	interpret(input Value) Value
+	interpret(env *Environment, input Value) Value
 }
 
 type Statement interface {
@@ -207,6 +209,203 @@ func (node Literal) getChildren() []Node {
 	return nil
 }
 
+// Template is a string literal with embedded expressions, such as `${a} and ${b}`.
+// Its parts are the expressions along with the literals between them.
+type Template struct {
+	parts []Expression
+	pos   Position
+}
+
+func (node Template) getPosition() Position {
+	return node.pos
+}
+
+func (node Template) toString() string {
+	return "<template>"
+}
+
+func (node Template) getChildren() []Node {
+	arr := make([]Node, len(node.parts))
+	for i := range node.parts {
+		arr[i] = node.parts[i]
+	}
+	return arr
+}
+
+// Match picks the first of its arms whose pattern matches the value of its subject
+// (and whose guard, if it has one, is true) and evaluates the arm's result.
+type Match struct {
+	subject Expression
+	arms    []MatchArm
+	pos     Position
+}
+
+type MatchArm struct {
+	pattern Pattern
+	guard   Expression
+	result  Expression
+}
+
+func (node Match) getPosition() Position {
+	return node.pos
+}
+
+func (node Match) toString() string {
+	return "<match>"
+}
+
+func (node Match) getChildren() []Node {
+	ret := []Node{node.subject}
+	for _, arm := range node.arms {
+		ret = append(ret, arm.pattern, arm.guard, arm.result)
+	}
+	return ret
+}
+
+// Where evaluates its bindings once, in order, and then evaluates its expression with
+// the names of the bindings bound to their values.
+type Where struct {
+	expression Expression
+	bindings   []Binding
+	pos        Position
+}
+
+// Binding binds a name to the value of an expression.
+type Binding struct {
+	id    Identifier
+	value Expression
+}
+
+func (node Where) getPosition() Position {
+	return node.pos
+}
+
+func (node Where) toString() string {
+	return "<where>"
+}
+
+func (node Where) getChildren() []Node {
+	ret := []Node{node.expression}
+	for _, b := range node.bindings {
+		ret = append(ret, b.id, b.value)
+	}
+	return ret
+}
+
+type patternKind int
+
+const (
+	PAT_WILDCARD patternKind = iota
+	PAT_BIND
+	PAT_LITERAL
+	PAT_REGEX
+	PAT_LIST
+)
+
+// Pattern is the pattern of a match arm. value holds the name bound by PAT_BIND patterns,
+// the string matched by PAT_LITERAL patterns and the source of PAT_REGEX patterns. A
+// PAT_LIST pattern matches a list whose values match elems; if it has a rest, the values
+// after those are bound as a list to the name in rest ("_" to not bind them).
+type Pattern struct {
+	kind  patternKind
+	value string
+	re    *regexp.Regexp
+	elems []Pattern
+	rest  string
+	pos   Position
+}
+
+func (node Pattern) getPosition() Position {
+	return node.pos
+}
+
+func (node Pattern) toString() string {
+	switch node.kind {
+	case PAT_WILDCARD:
+		return "_"
+	case PAT_LITERAL:
+		return "\"" + node.value + "\""
+	case PAT_REGEX:
+		return "re\"" + node.value + "\""
+	case PAT_LIST:
+		if node.rest != "" {
+			return "(" + node.rest + "...)"
+		}
+		return "()"
+	default:
+		return node.value
+	}
+}
+
+func (node Pattern) getChildren() []Node {
+	arr := make([]Node, len(node.elems))
+	for i := range node.elems {
+		arr[i] = node.elems[i]
+	}
+	return arr
+}
+
+type MemberAccess struct {
+	expression Expression
+	member     Identifier
+	pos        Position
+}
+
+func (node MemberAccess) getPosition() Position {
+	return node.pos
+}
+
+func (node MemberAccess) toString() string {
+	return "."
+}
+
+func (node MemberAccess) getChildren() []Node {
+	return []Node{node.expression, node.member}
+}
+
+type Import struct {
+	path  string
+	alias Identifier
+	pos   Position
+}
+
+func (node Import) getPosition() Position {
+	return node.pos
+}
+
+func (node Import) toString() string {
+	return "import \"" + node.path + "\""
+}
+
+func (node Import) getChildren() []Node {
+	if node.alias.id == "" {
+		return nil
+	}
+	return []Node{node.alias}
+}
+
+type MapLiteral struct {
+	keys []Expression
+	vals []Expression
+	pos  Position
+}
+
+func (node MapLiteral) getPosition() Position {
+	return node.pos
+}
+
+func (node MapLiteral) toString() string {
+	return "<map>"
+}
+
+func (node MapLiteral) getChildren() []Node {
+	arr := []Node{}
+	for i := range node.keys {
+		arr = append(arr, node.keys[i], node.vals[i])
+	}
+	return arr
+}
+
 type Subscript struct {
 	expression Expression
 	idx1       Expression
@@ -237,7 +436,12 @@ func (node Subscript) getChildren() []Node {
 
 type IdentifierList struct {
 	identifiers []Identifier
-	pos         Position
+	// defaults holds the default value of each parameter of a definition, which is nil
+	// for parameters without one. It is nil if no parameter has a default value.
+	defaults []Expression
+	// variadic is set if the last identifier collects the parameters after the others.
+	variadic bool
+	pos      Position
 }
 
 func (node IdentifierList) getPosition() Position {
@@ -256,11 +460,32 @@ func (node IdentifierList) getChildren() []Node {
 	return arr
 }
 
+// VariadicParameter is the last parameter of a definition which collects the
+// parameters after the others, as in "f(a, rest...) => ...".
+type VariadicParameter struct {
+	id  Identifier
+	pos Position
+}
+
+func (node VariadicParameter) getPosition() Position {
+	return node.pos
+}
+
+func (node VariadicParameter) toString() string {
+	return "..."
+}
+
+func (node VariadicParameter) getChildren() []Node {
+	return []Node{node.id}
+}
+
 type Definition struct {
 	id      Identifier
 	params  IdentifierList
 	content Program
 	pos     Position
+	// memo is set for definitions marked with "memo", whose results are cached.
+	memo bool
 }
 
 func (node Definition) getPosition() Position {
@@ -0,0 +1,1052 @@
+package main
+
+// generated by docsgen.py
+
+func showHelp(cmd string) {
+	s := ""
+	if len(cmd) >= 6 {
+		s = cmd[5 : len(cmd)-1]
+	}
+	switch s {
+	default:
+		globals.outputColor.Println(`No help exists for "` + s + `".`)
+	case "":
+		globals.outputColor.Println(`Use "help xxx" to see help for a particular subject.
+Or read the Language Specification: gitlab.com/QazmoQwerty/trex/-/blob/master/docs/trex-spec.md`)
+	case "example":
+		globals.outputColor.Println(`Use "example xxx" to an example of a particular subject.`)
+	case "help":
+		globals.outputColor.Println(`Use "help xxx" to see help for a particular subject.`)
+	case "exit":
+		globals.outputColor.Print(`
+"exit":
+Exits the interpreter. Identical to "quit".
+Input: none
+Parameters: none
+`)
+	case "quit":
+		globals.outputColor.Print(`
+"quit":
+Exits the interpreter. Identical to "exit".
+Input: a list
+Parameters: none
+`)
+	case "any":
+		printDoc(`
+"any":
+Returns true if calling a definition on any of the values of a list returns a true value, otherwise false. Stops at the first such value.
+Input: a list.
+Parameters: 1
+* The definition which is called on each value
+Tip: try "example any" to see an example.
+`)
+	case "ascii":
+		printDoc(`
+"ascii":
+Returns a list of numbers, with every number representing the ASCII value of the corresponding character in the string.
+Input: a string
+Parameters: none
+Tip: try "example ascii" to see an example.
+`)
+	case "bool":
+		printDoc(`
+"bool":
+Returns true if the input counts as true in a condition, otherwise false.
+Input: a string
+Parameters: none
+Tip: try "example bool" to see an example.
+`)
+	case "captures":
+		printDoc(`
+"captures":
+Finds all matches of a regular expression within a string, and returns a list of the groups of each match. Groups which did not take part in a match are empty.
+Input: a string
+Parameters: 1
+* The regular expression to match
+Tip: try "example captures" to see an example.
+`)
+	case "chars":
+		printDoc(`
+"chars":
+Splits a given string into a list of single characters.
+Input: a string.
+Parameters: none
+Tip: try "example chars" to see an example.
+`)
+	case "count":
+		printDoc(`
+"count":
+Returns the number of values in a given list, or the number of keys in a map.
+Input: a list or a map.
+Parameters: none
+Tip: try "example count" to see an example.
+`)
+	case "counts":
+		printDoc(`
+"counts":
+Returns a map from every distinct value in a list to the number of times it occurs in the list.
+Input: a list.
+Parameters: none
+Tip: try "example counts" to see an example.
+`)
+	case "csv":
+		printDoc(`
+"csv":
+Parses a string of comma-separated values into a list of rows, each of which is a list of its fields. Fields may be quoted, so they can contain commas, quotes and newlines. If the parameter is true, the first row is taken to be a header, and every other row is returned as a map from the column names to its fields.
+Input: a string.
+Parameters: 0 or 1
+* Whether the first row is a header (optional, false by default)
+Tip: try "example csv" to see an example.
+`)
+	case "endswith":
+		printDoc(`
+"endswith":
+Checks whether a given string ends with a specified suffix.
+Input: a string.
+Parameters: 1
+* The suffix
+Tip: try "example endswith" to see an example.
+`)
+	case "first":
+		printDoc(`
+"first":
+Returns the first value of a list, or null if the list is empty. Values after the first one are not computed.
+Input: a list.
+Parameters: none
+Tip: try "example first" to see an example.
+`)
+	case "fold":
+		printDoc(`
+"fold":
+Applies a right fold to a list. Equivalent to 'foldr'.
+Input: a list
+Parameters: 1
+* The definition by which to fold fold the values
+Tip: try "example fold" to see an example.
+`)
+	case "foldl":
+		printDoc(`
+"foldl":
+Applies a left fold to a list.
+Input: a list
+Parameters: 1
+* The definition by which to fold fold the values
+Tip: try "example foldl" to see an example.
+`)
+	case "foldr":
+		printDoc(`
+"foldr":
+Applies a right fold to a list.
+Input: a list
+Parameters: 1
+* The definition by which to fold fold the values
+Tip: try "example foldr" to see an example.
+`)
+	case "formattime":
+		printDoc(`
+"formattime":
+Writes a time with a given layout, which is a layout like those of parsetime.
+Input: a time.
+Parameters: 1
+* The layout
+Tip: try "example formattime" to see an example.
+`)
+	case "fromcsv":
+		printDoc(`
+"fromcsv":
+Like csv, for values which are separated by a given character.
+Input: a string.
+Parameters: 1 or 2
+* The character which separates the values
+* Whether the first row is a header (optional, false by default)
+Tip: try "example fromcsv" to see an example.
+`)
+	case "fromjson":
+		printDoc(`
+"fromjson":
+Parses a JSON value. Objects become maps, arrays become lists, strings become strings, numbers become strings of the number as it is written, true and false become booleans and null becomes null.
+Input: a string.
+Parameters: none
+Tip: try "example fromjson" to see an example.
+`)
+	case "get":
+		printDoc(`
+"get":
+Returns the value at a path within maps and lists, or null if there is no such value. A path is made of map keys separated by dots, and of list indices in square brackets, which count from the end if they are negative. Keys which contain dots or brackets are quoted in square brackets, as in a['b.c'].
+Input: a map or a list.
+Parameters: 1
+* The path
+Tip: try "example get" to see an example.
+`)
+	case "groupby":
+		printDoc(`
+"groupby":
+Groups the values of a list into a map, by the result of calling a definition on each of them.
+Input: a list.
+Parameters: 1
+* The definition which returns the key of each value
+Tip: try "example groupby" to see an example.
+`)
+	case "hasmatch":
+		printDoc(`
+"hasmatch":
+Finds whether a regular expression has a match whithin a string.
+Input: a string
+Parameters: 1
+* The regular expression to match 
+Tip: try "example hasmatch" to see an example.
+`)
+	case "indexby":
+		printDoc(`
+"indexby":
+Finds the index of the first character which satisfies the definition. Returns -1 if no character satisfies the definition.
+Input: a string.
+Parameters: 1
+* The definition
+Tip: try "example indexby" to see an example.
+`)
+	case "indexof":
+		printDoc(`
+"indexof":
+Finds the index of the first instance of a substring. Returns -1 if the substring is not found.
+Input: a string.
+Parameters: 1
+* The substring to find
+Tip: try "example indexof" to see an example.
+`)
+	case "isalnum":
+		printDoc(`
+"isalnum":
+Checks whether if all characters in a string are alphanumeric and there is at least one character.
+Input: a string.
+Parameters: none
+Tip: try "example isalnum" to see an example.
+`)
+	case "isalpha":
+		printDoc(`
+"isalpha":
+Checks if all characters in a string are alphabetic and there is at least one character.
+Input: a string.
+Parameters: none
+Tip: try "example isalpha" to see an example.
+`)
+	case "isdigit":
+		printDoc(`
+"isdigit":
+Checks if a string is a single digit.
+Input: a string
+Parameters: none
+Tip: try "example isdigit" to see an example.
+`)
+	case "isempty":
+		printDoc(`
+"isempty":
+Checks whether a value is null, an empty string, or a list or map with nothing in it.
+Input: any value.
+Parameters: none
+Tip: try "example isempty" to see an example.
+`)
+	case "isletter":
+		printDoc(`
+"isletter":
+Checks if a string is a single letter.
+Input: a string
+Parameters: none
+Tip: try "example isletter" to see an example.
+`)
+	case "islower":
+		printDoc(`
+"islower":
+Checks if a string is comprised only of lowercase letters.
+Input: a string
+Parameters: none
+Tip: try "example islower" to see an example.
+`)
+	case "isnull":
+		printDoc(`
+"isnull":
+Checks whether a value is null.
+Input: any value.
+Parameters: none
+Tip: try "example isnull" to see an example.
+`)
+	case "isnum":
+		printDoc(`
+"isnum":
+Checks if all characters in a string are numeric and there is at least one character.
+Input: a string.
+Parameters: none
+Tip: try "example isnum" to see an example.
+`)
+	case "isspace":
+		printDoc(`
+"isspace":
+Checks if there are only whitespace characters in the string and there is at least one character
+Input: a string.
+Parameters: none
+Tip: try "example isspace" to see an example.
+`)
+	case "istitle":
+		printDoc(`
+"istitle":
+Checks if all words in a string begin with an uppercase letter and are otherwise are lowercase.
+Input: a string.
+Parameters: none
+Tip: try "example istitle" to see an example.
+`)
+	case "isupper":
+		printDoc(`
+"isupper":
+Checks if a string is comprised only of uppercase letters.
+Input: a string
+Parameters: none
+Tip: try "example isupper" to see an example.
+`)
+	case "join":
+		printDoc(`
+"join":
+Joins all elements in a list into a single string.
+Input: a list
+Parameters: none
+Tip: try "example join" to see an example.
+`)
+	case "keys":
+		printDoc(`
+"keys":
+Returns the keys of a map, in the order they were added in.
+Input: a map.
+Parameters: none
+Tip: try "example keys" to see an example.
+`)
+	case "lastindexby":
+		printDoc(`
+"lastindexby":
+Finds the index of the last character which satisfies the definition. Returns -1 if no character satisfies the definition.
+Input: a string.
+Parameters: 1
+* The definition
+Tip: try "example lastindexby" to see an example.
+`)
+	case "lastindexof":
+		printDoc(`
+"lastindexof":
+Finds the index of the last instance of a substring. Returns -1 if the substring is not found.
+Input: a string.
+Parameters: 1
+* The substring to find
+Tip: try "example lastindexof" to see an example.
+`)
+	case "len":
+		printDoc(`
+"len":
+Returns the length of a given string.
+Input: a string.
+Parameters: none
+Tip: try "example len" to see an example.
+`)
+	case "lines":
+		printDoc(`
+"lines":
+Splits a given string into lines.
+Input: a string.
+Parameters: none
+Tip: try "example lines" to see an example.
+`)
+	case "matches":
+		printDoc(`
+"matches":
+Finds all matches of a regular expression whithin a string.
+Input: a string
+Parameters: 1
+* The regular expression to match 
+Tip: try "example matches" to see an example.
+`)
+	case "max":
+		printDoc(`
+"max":
+Finds the largest value in a list based on a specified order.
+Input: a list.
+Parameters: 1
+* the definition by which to order the values, which must return a value 
+Tip: try "example max" to see an example.
+`)
+	case "min":
+		printDoc(`
+"min":
+Finds the smallest value in a list based on a specified order.
+Input: a list.
+Parameters: 1
+* the definition by which to order the values, which must return a value 
+Tip: try "example min" to see an example.
+`)
+	case "named":
+		printDoc(`
+"named":
+Finds the first match of a regular expression within a string, and returns a map from the names of its named groups to what they matched, or null if there is no match.
+Input: a string
+Parameters: 1
+* The regular expression to match
+Tip: try "example named" to see an example.
+`)
+	case "now":
+		printDoc(`
+"now":
+Returns the current time.
+Input: none
+Parameters: none
+Tip: try "example now" to see an example.
+`)
+	case "numoccurs":
+		printDoc(`
+"numoccurs":
+Returns the number of times a value occurs inside a given list or string.
+Input: a list or string.
+Parameters: 1
+* the value to count occurences of
+Tip: try "example numoccurs" to see an example.
+`)
+	case "pairs":
+		printDoc(`
+"pairs":
+Returns a list holding a (key, value) list for every entry of a map.
+Input: a map.
+Parameters: none
+Tip: try "example pairs" to see an example.
+`)
+	case "parsetime":
+		printDoc(`
+"parsetime":
+Reads a time which is written with a given layout, and returns it as a time. Times are strings written like 2024-03-01T12:30:45Z (as in RFC 3339), which keep the time zone they were read in; times without a time zone are in UTC. A layout is either a strftime layout such as '%Y-%m-%d %H:%M:%S' (with the directives %Y %y %m %d %e %H %I %M %S %f %p %b %B %a %A %z %Z %F %T %D and %%, and any other text written as it is, where %Z only reads UTC, GMT and the abbreviations of the local time zone), a Go layout such as '2006-01-02 15:04:05', or 'unix' for a number of seconds since 1970-01-01T00:00:00Z.
+Input: a string.
+Parameters: 0 or 1
+* The layout (optional, by default the time must already be written like a time)
+Tip: try "example parsetime" to see an example.
+`)
+	case "replace":
+		printDoc(`
+"replace":
+Replaces all occurences of a certain string whithin a string with another string.
+Input: a string
+Parameters:
+* the string to search for 
+* the string to replace with
+Tip: try "example replace" to see an example.
+`)
+	case "resplit":
+		printDoc(`
+"resplit":
+Splits a string at every match of a regular expression.
+Input: a string
+Parameters: 1
+* The regular expression which separates the parts
+Tip: try "example resplit" to see an example.
+`)
+	case "resub":
+		printDoc(`
+"resub":
+Replaces all matches of a regular expression within a string. The replacement is either a string, in which $1 (or ${1}) stands for what the first group matched and ${name} stands for what the group with that name matched, or a definition, which is called for each match with the match as its argument. If the definition takes parameters it is also given the groups as its parameters, and otherwise (as do built-in definitions) it is given none.
+Input: a string
+Parameters: 2
+* The regular expression to match
+* The replacement
+Tip: try "example resub" to see an example.
+`)
+	case "reverse":
+		printDoc(`
+"reverse":
+Reverses a string or list.
+Input: a string or list
+Parameters: none
+Tip: try "example reverse" to see an example.
+`)
+	case "sort":
+		printDoc(`
+"sort":
+Sorts a list (ascending) based on a specified order.
+Input: a list.
+Parameters: 1
+* the definition by which to order the values, which must return a value convertible to a number.
+Tip: try "example sort" to see an example.
+`)
+	case "split":
+		printDoc(`
+"split":
+Splits a string into a list based on a seperator.
+Input: a string.
+Expected number of parameters: 1
+* The seperator string
+Tip: try "example split" to see an example.
+`)
+	case "startswith":
+		printDoc(`
+"startswith":
+Checks whether a given string starts with a specified prefix.
+Input: a string.
+Parameters: 1
+* The prefix
+Tip: try "example startswith" to see an example.
+`)
+	case "swapcase":
+		printDoc(`
+"swapcase":
+Swaps uppercase letters with their lowercase counterparts and vice versa. 
+Input: a string
+Parameters: none
+Tip: try "example swapcase" to see an example.
+`)
+	case "take":
+		printDoc(`
+"take":
+Returns the first values of a list. Only the values which are returned are computed, so it can be used on very long sequences.
+Input: a list.
+Parameters: 1
+* The number of values to return
+Tip: try "example take" to see an example.
+`)
+	case "timediff":
+		printDoc(`
+"timediff":
+Returns the number of seconds from a given time to the input time, which is negative if the input time is the earlier one.
+Input: a time.
+Parameters: 1
+* The time to count from
+Tip: try "example timediff" to see an example.
+`)
+	case "tocsv":
+		printDoc(`
+"tocsv":
+Turns a list of rows into comma-separated values, quoting the fields which need it. Each row is either a list of fields, or a map from column names to fields, in which case the keys of the first row are written as a header.
+Input: a list of rows.
+Parameters: 0 or 1
+* The character which separates the values (optional, ',' by default)
+Tip: try "example tocsv" to see an example.
+`)
+	case "tojson":
+		printDoc(`
+"tojson":
+Turns a value into JSON. Maps become objects, lists become arrays, booleans become true and false and null becomes null. Strings which are written like JSON numbers become numbers, and all other strings become strings.
+Input: any value other than a definition or a module.
+Parameters: none
+Tip: try "example tojson" to see an example.
+`)
+	case "tolower":
+		printDoc(`
+"tolower":
+Returns the input with all unicode letters mapped to their lower case.
+Input: a string.
+Parameters: none
+Tip: try "example tolower" to see an example.
+`)
+	case "totitle":
+		printDoc(`
+"totitle":
+Converts the letters at the beginning of each word to uppercase.
+Input: a string
+Parameters: none
+Tip: try "example totitle" to see an example.
+`)
+	case "toupper":
+		printDoc(`
+"toupper":
+Returns the input with all unicode letters mapped to their upper case.
+Input: a string.
+Parameters: none
+Tip: try "example toupper" to see an example.
+`)
+	case "truncatetime":
+		printDoc(`
+"truncatetime":
+Rounds a time down to a whole unit, which is second, minute, hour, day, week (starting on Monday), month or year, or a Go duration such as 15m.
+Input: a time.
+Parameters: 1
+* The unit
+Tip: try "example truncatetime" to see an example.
+`)
+	case "tsv":
+		printDoc(`
+"tsv":
+Like csv, for values which are separated by tabs.
+Input: a string.
+Parameters: 0 or 1
+* Whether the first row is a header (optional, false by default)
+Tip: try "example tsv" to see an example.
+`)
+	case "unique":
+		printDoc(`
+"unique":
+Returns a list of all unique values in a given list.
+Input: a list.
+Parameters: none
+Tip: try "example unique" to see an example.
+`)
+	case "unixtime":
+		printDoc(`
+"unixtime":
+Returns the number of seconds from 1970-01-01T00:00:00Z to a time.
+Input: a time.
+Parameters: none
+Tip: try "example unixtime" to see an example.
+`)
+	case "values":
+		printDoc(`
+"values":
+Returns the values of a map, in the order their keys were added in.
+Input: a map.
+Parameters: none
+Tip: try "example values" to see an example.
+`)
+	case "words":
+		printDoc(`
+"words":
+Splits a given string into words.
+Input: a string.
+Parameters: none
+Tip: try "example words" to see an example.
+`)
+	}
+}
+
+func showExample(cmd string) {
+	s := ""
+	if len(cmd) >= 9 {
+		s = cmd[8 : len(cmd)-1]
+	}
+	switch s {
+	default:
+		globals.outputColor.Println(`No example exists for "` + s + `".`)
+	case "":
+		globals.outputColor.Println(`Try "example xxx" to see an example for a particular subject.`)
+	case "example":
+		globals.outputColor.Print(`
+--> example example
+[Do you really need to see this?]
+`)
+	case "help":
+		globals.outputColor.Print(`
+--> help example
+[help for for how to use the example command]
+`)
+	case "quit":
+		globals.outputColor.Print(`
+--> quit
+[trex will exit]
+`)
+	case "exit":
+		globals.outputColor.Print(`
+--> exit
+[trex will exit]
+`)
+	case "any":
+		printDoc(`
+--> any(-> [] > 3) (1, 5, 2)
+true
+`)
+	case "ascii":
+		printDoc(`
+--> ascii 0123
+48, 49, 50, 51
+`)
+	case "bool":
+		printDoc(`
+--> bool (1 = 2)
+false
+--> bool (12 > 4)
+true
+`)
+	case "captures":
+		printDoc(`
+--> captures('(\w+)=(\d+)') 'a=1 b=2 c=x'
+(a, 1), (b, 2)
+`)
+	case "chars":
+		printDoc(`
+--> chars 12343
+1, 2, 3, 4, 3
+`)
+	case "count":
+		printDoc(`
+--> lines
+one, two, three
+--> count lines
+3
+`)
+	case "counts":
+		printDoc(`
+--> counts words 'a b a c a'
+{a: 3, b: 1, c: 1}
+`)
+	case "csv":
+		printDoc(`
+--> csv ('name,city' << \n << 'bob,"Paris, FR"')
+(name, city), (bob, Paris, FR)
+--> csv(true) ('name,city' << \n << 'bob,"Paris, FR"')
+{name: bob, city: Paris, FR}
+`)
+	case "endswith":
+		printDoc(`
+--> bool endswith('ab') 'kabab'
+true
+`)
+	case "first":
+		printDoc(`
+--> first (x from 10..100000000 if x % 7 = 0)
+14
+`)
+	case "fold":
+		printDoc(`
+--> fold(a,b -> a+b) (1, 2, 3, 4, 5)
+15
+`)
+	case "foldl":
+		printDoc(`
+--> fold(a,b -> a+b) (1, 2, 3, 4, 5)
+15
+`)
+	case "foldr":
+		printDoc(`
+--> fold(a,b -> a+b) (1, 2, 3, 4, 5)
+15
+`)
+	case "formattime":
+		printDoc(`
+--> formattime('%d %B %Y, %I:%M %p') '2024-03-01T15:30:45Z'
+01 March 2024, 03:30 PM
+--> formattime('%A the %dth, 2006') '2024-03-05T12:00:00Z'
+Tuesday the 05th, 2006
+`)
+	case "fromcsv":
+		printDoc(`
+--> fromcsv(';') 'a;"b;c"'
+(a, b;c)
+`)
+	case "fromjson":
+		printDoc(`
+--> fromjson '{"name": "bob", "tags": ["a", "b"], "age": 34, "boss": null}'
+{name: bob, tags: (a, b), age: 34, boss: }
+--> (fromjson '{"user": {"name": "bob"}}').user.name
+bob
+`)
+	case "get":
+		printDoc(`
+--> get('user.tags[-1]') fromjson '{"user": {"tags": ["a", "b"]}}'
+b
+--> isnull get('user.age') fromjson '{"user": {"tags": ["a", "b"]}}'
+true
+`)
+	case "groupby":
+		printDoc(`
+--> groupby(#len) words 'aa b cc'
+{2: (aa, cc), 1: (b)}
+`)
+	case "hasmatch":
+		printDoc(`
+--> bool hasmatch('a[a-z]') "abbbjaja"
+true
+`)
+	case "indexby":
+		printDoc(`
+--> indexby(->[] = 'a' or [] = 'b') "this is a string"
+8
+`)
+	case "indexof":
+		printDoc(`
+--> indexof("s") "this is a string"
+3
+`)
+	case "isalnum":
+		printDoc(`
+--> bool isalnum 'abc12'
+true
+--> bool isalnum 'ab$$1'
+false
+`)
+	case "isalpha":
+		printDoc(`
+--> bool isalpha 'abc12'
+true
+--> bool isalpha 'ab$$1'
+false
+`)
+	case "isdigit":
+		printDoc(`
+--> bool isdigit 1
+true
+--> bool isdigit 'a'
+false
+--> bool isdigit 12
+false
+`)
+	case "isempty":
+		printDoc(`
+--> isempty ''
+true
+--> isempty false
+false
+`)
+	case "isletter":
+		printDoc(`
+--> bool isletter 1
+false
+--> bool isletter 'a'
+true
+--> bool isletter 'aa'
+false
+`)
+	case "islower":
+		printDoc(`
+--> bool islower 'A'
+false
+--> bool islower 'aa'
+true
+`)
+	case "isnull":
+		printDoc(`
+--> isnull null
+true
+--> isnull ''
+false
+`)
+	case "isnum":
+		printDoc(`
+--> bool isnum 13
+true
+--> bool isnum 'ab'
+false
+`)
+	case "isspace":
+		printDoc(`
+--> bool isspace '  '
+true
+`)
+	case "istitle":
+		printDoc(`
+--> bool istitle 'Her Royal Highness'
+true
+`)
+	case "isupper":
+		printDoc(`
+--> bool isupper 'a'
+false
+--> bool isupper 'AA'
+true
+`)
+	case "join":
+		printDoc(`
+--> join (1, 2, 3, 4, 5)
+12345
+`)
+	case "keys":
+		printDoc(`
+--> keys ({a: 1, b: 2})
+a, b
+`)
+	case "lastindexby":
+		printDoc(`
+--> lastindexby(->[] = 'a' or [] = 'b') "kabab"
+4
+`)
+	case "lastindexof":
+		printDoc(`
+--> lastindexof("s") "this is a string"
+10
+`)
+	case "len":
+		printDoc(`
+--> len "example"
+7
+`)
+	case "lines":
+		printDoc(`
+--> []
+one
+two
+three
+--> lines
+one, two, three
+`)
+	case "matches":
+		printDoc(`
+--> matches('a[a-z]') "abbbjaja"
+ab, aj
+`)
+	case "max":
+		printDoc(`
+--> []
+word
+another
+foo
+--> max(#len)
+another
+`)
+	case "min":
+		printDoc(`
+--> []
+word
+another
+foo
+--> min(#len)
+foo
+`)
+	case "named":
+		printDoc(`
+--> named('(?P<ip>[\d.]+) - (?P<user>\w+)') '10.0.0.1 - bob GET'
+{ip: 10.0.0.1, user: bob}
+`)
+	case "now":
+		printDoc(`
+--> now
+2024-03-01T12:30:45.123456789+02:00
+`)
+	case "numoccurs":
+		printDoc(`
+--> numoccurs('fo') 'foobafo'
+2
+`)
+	case "pairs":
+		printDoc(`
+--> pairs ({a: 1, b: 2})
+(a, 1), (b, 2)
+`)
+	case "parsetime":
+		printDoc(`
+--> parsetime('%Y-%m-%d %H:%M:%S') '2024-03-01 12:30:45'
+2024-03-01T12:30:45Z
+--> parsetime('%d/%b/%Y:%H:%M:%S %z') '10/Oct/2000:13:55:36 -0700'
+2000-10-10T13:55:36-07:00
+--> parsetime('unix') 1700000000
+2023-11-14T22:13:20Z
+`)
+	case "replace":
+		printDoc(`
+--> replace('a', 'AA') 'a bar'
+AA bAAr
+`)
+	case "resplit":
+		printDoc(`
+--> resplit(',\s*') 'a, b,c,   d'
+a, b, c, d
+`)
+	case "resub":
+		printDoc(`
+--> resub('(\w+)@(\w+)', '$2 at $1') 'bob@host, ann@box'
+host at bob, box at ann
+--> resub('\d+', -> [] * 2) 'a1 b20'
+a2 b40
+--> resub('(\w+)@(\w+)', ((user, host) -> toupper user << '@' << host)) 'bob@host'
+BOB@host
+--> resub('(o)', #toupper) 'foo'
+fOO
+`)
+	case "reverse":
+		printDoc(`
+--> reverse (1, 2, 3, 4)
+4, 3, 2, 1
+--> reverse 1234
+4321
+`)
+	case "sort":
+		printDoc(`
+--> words
+one, three, four
+--> sort(#len) words
+one, four, three
+`)
+	case "split":
+		printDoc(`
+--> split(' ') "12 13 14 15"
+12, 13, 14, 15
+`)
+	case "startswith":
+		printDoc(`
+--> bool startswith('tr') 'trex'
+true
+`)
+	case "swapcase":
+		printDoc(`
+--> swapcase "Her Royal Highness"
+hER rOYAL hIGHNESS
+`)
+	case "take":
+		printDoc(`
+--> take(3) words 'one two three four five'
+one, two, three
+`)
+	case "timediff":
+		printDoc(`
+--> timediff('2024-03-01T12:00:00Z') '2024-03-01T13:30:00Z'
+5400
+`)
+	case "tocsv":
+		printDoc(`
+--> tocsv (('a', 'b, c'), ('d'))
+a,"b, c"
+d
+--> tocsv csv(true) ('name,city' << \n << 'bob,Rome')
+name,city
+bob,Rome
+`)
+	case "tojson":
+		printDoc(`
+--> tojson ({name: 'bob', tags: ('a', 'b'), age: 34, boss: null})
+{"name":"bob","tags":["a","b"],"age":34,"boss":null}
+--> tojson ('007', '1.50', 'x')
+["007",1.50,"x"]
+`)
+	case "tolower":
+		printDoc(`
+--> tolower "Hello World"
+hello world
+`)
+	case "totitle":
+		printDoc(`
+--> totitle "her royal highness"
+Her Royal Highness
+`)
+	case "toupper":
+		printDoc(`
+--> toupper "Hello World"
+HELLO WORLD
+`)
+	case "truncatetime":
+		printDoc(`
+--> truncatetime('hour') '2024-03-01T12:30:45+02:00'
+2024-03-01T12:00:00+02:00
+--> truncatetime('15m') '2024-03-01T12:37:45Z'
+2024-03-01T12:30:00Z
+`)
+	case "tsv":
+		printDoc(`
+--> tsv ('a' << \t << 'b')
+(a, b)
+`)
+	case "unique":
+		printDoc(`
+--> foo => 1, 2, 3, 4, 4, 3, 2, 1, 3, 7
+--> unique foo
+1, 2, 3, 4, 7
+`)
+	case "unixtime":
+		printDoc(`
+--> unixtime '2024-03-01T12:30:45.25Z'
+1709296245.25
+`)
+	case "values":
+		printDoc(`
+--> values ({a: 1, b: 2})
+1, 2
+`)
+	case "words":
+		printDoc(`
+--> foo => "this is a sentence"
+--> words foo
+this, is, a, sentence
+`)
+	}
+}
+
+// printDoc prints documentation of a built-in definition. Documentation may contain
+// '%' (as time layouts do), so it is not passed straight to Print where it would look
+// like a format string.
+func printDoc(doc string) {
+	globals.outputColor.Print(doc)
+}
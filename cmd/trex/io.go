@@ -0,0 +1,261 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"gitlab.com/QazmoQwerty/go-liner-highlight"
+	"gitlab.com/QazmoQwerty/trex"
+)
+
+var trexKeywords = []string{
+	"if", "else", "for", "in", "from", "not", "or", "and", "import", "as", "match", "where", "memo",
+}
+
+var wordOperators = []string{
+	"else", "for", "in", "and", "if", "from", "or", "not", "import", "as", "match", "where", "memo", "exit", "help", "quit", "example",
+}
+
+// Note: we should put the longest operators first.
+var trexOperators = []string{
+	"=", "!", "<", ">", "#", "+", "-", "*", "/", "%", ":",
+	"|", "[", "{", "(", "]", "}", ")", ",", ".",
+}
+
+var colors = map[liner.Category](*color.Color){
+	liner.NumberType:   color.New(color.FgHiRed),
+	liner.StringType:   color.New(color.FgHiRed),
+	liner.KeywordType:  color.New(color.FgHiBlue),
+	liner.CommentType:  color.New(color.FgHiGreen),
+	liner.FunctionType: color.New(color.FgHiYellow),
+	liner.OperatorType: color.New(),
+	liner.IdentType:    color.New(),
+}
+
+func ioSetup() {
+	globals.liner = liner.NewLiner()
+	globals.liner.SetWordCompleter(wordCompleter)
+	globals.liner.SetSyntaxHighlight(globals.interpreterSyntaxHighlight)
+	globals.liner.RegisterOperators(trexOperators)
+	globals.liner.RegisterKeywords(trexKeywords)
+	globals.liner.RegisterColors(colors)
+	globals.liner.RegisterFunctions(trex.Builtins())
+	globals.liner.RegisterFunctions([]string{"exit", "quit", "help", "example"})
+}
+
+func wordCompleter(line string, pos int) (string, []string, string) {
+	if len(line) == 0 {
+		return "", []string{"help", "exit", ""}, ""
+	}
+
+	low := pos - 1
+	if low < 0 || low > len([]rune(line)) || !unicode.IsLetter([]rune(line)[low]) {
+		return line[:pos], []string{}, line[pos:]
+	}
+	for low > 0 && unicode.IsLetter([]rune(line)[low-1]) {
+		low--
+	}
+	high := pos - 1
+	for high < len([]rune(line)) && unicode.IsLetter([]rune(line)[high]) {
+		high++
+	}
+	word := line[low:high]
+	toLower := strings.ToLower(word)
+
+	completions := []string{}
+
+	for _, k := range trex.Builtins() {
+		if strings.HasPrefix(strings.ToLower(k), toLower) {
+			completions = append(completions, k)
+		}
+	}
+	for _, k := range globals.engine.Names() {
+		if strings.HasPrefix(strings.ToLower(k), toLower) {
+			completions = append(completions, k)
+		}
+	}
+	for _, s := range wordOperators {
+		if strings.HasPrefix(strings.ToLower(s), toLower) {
+			completions = append(completions, s)
+		}
+	}
+	completions = append(completions, word)
+
+	return line[:low], completions, line[high:]
+}
+
+func ioExit() {
+	if globals.liner != nil {
+		globals.liner.Close()
+	}
+	os.Exit(0)
+}
+
+func readLine(prompt string) string {
+	line, err := globals.liner.Prompt(prompt)
+	if err == io.EOF {
+		ioExit()
+	} else if err != nil {
+		panic(err)
+	}
+	globals.liner.AppendHistory(line)
+	return line + "\n"
+}
+
+// readCode reads a piece of code from the user, which may span several lines if
+// they end with '\\' or open a '{' block. It returns "" if there is nothing to run.
+func readCode() string {
+	line := readLine(">>> ")
+	if line == "\n" {
+		return ""
+	}
+	if line == "exit\n" || line == "quit\n" {
+		ioExit()
+		return ""
+	} else if line == "help\n" || strings.HasPrefix(line, "help ") {
+		showHelp(line)
+		return ""
+	} else if line == "example\n" || strings.HasPrefix(line, "example ") {
+		showExample(line)
+		return ""
+	}
+	return readRestOfCode(line)
+}
+
+func readRestOfCode(line string) string {
+	if len(line) < 2 {
+		return line
+	}
+	switch line[len(line)-2] {
+	case '\\':
+		return line[:len(line)-2] + readRestOfCode(readLine("... "))
+	case '{':
+		for {
+			next := readLine("... ")
+			line += next
+			if next == "}\n" {
+				return line
+			}
+		}
+	default:
+		return line
+	}
+}
+
+func printError(err error, code string) {
+	errs := trex.Errors(err)
+	if errs == nil {
+		println(err.Error())
+		return
+	}
+	for _, e := range errs {
+		printTrexError(e, code)
+	}
+}
+
+func printTrexError(e trex.Error, code string) {
+	pos := e.Pos()
+	if trace := e.Trace(); len(trace) > 1 || (len(trace) == 1 && trace[0].Pos() != pos) {
+		printTrace(trace)
+	}
+	if !printSnippet(pos, code, '^', "") {
+		println("an internal error occurred...")
+		return
+	}
+	globals.errorColor.Fprint(os.Stderr, "Error["+e.Code().String()+"]:")
+	println(" " + e.Message())
+	for _, l := range e.Labels() {
+		printSnippet(l.Pos(), "", '-', l.Message())
+	}
+	blueBold := color.New(color.FgBlue).Add(color.Bold).FprintfFunc()
+	for _, note := range e.Notes() {
+		blueBold(os.Stderr, "  = note: ")
+		println(note)
+	}
+	for _, hint := range e.Hints() {
+		blueBold(os.Stderr, "  = help: ")
+		println(hint)
+	}
+}
+
+// printTrace prints the calls which led to an error, like Python's tracebacks do.
+// Consecutive identical calls, such as those made by a recursive definition, are
+// only printed once.
+func printTrace(trace []trex.Frame) {
+	whiteBold := color.New(color.FgWhite).Add(color.Bold).FprintfFunc()
+	println("Traceback (most recent call last):")
+	repeated := 0
+	flushRepeated := func() {
+		if repeated > 0 {
+			println("  [previous call repeated " + strconv.Itoa(repeated) + " more times]")
+			repeated = 0
+		}
+	}
+	for i, frame := range trace {
+		caller := "<top level>"
+		if i > 0 {
+			caller = trace[i-1].Name()
+		}
+		if i > 1 && frame == trace[i-1] && trace[i-1] == trace[i-2] {
+			repeated++
+			continue
+		}
+		flushRepeated()
+		pos := frame.Pos()
+		whiteBold(os.Stderr, "  ")
+		if src := pos.Source(); src != nil && src.Name != "" {
+			whiteBold(os.Stderr, "%s:", src.Name)
+		}
+		whiteBold(os.Stderr, "line %d, in %s\n", pos.Line(), caller)
+		if src := pos.Source(); src != nil && pos.Line() > 0 && pos.Line() <= strings.Count(src.Code, "\n")+1 {
+			println("    " + strings.TrimSpace(src.Line(pos.Line())))
+		}
+	}
+	flushRepeated()
+}
+
+// printSnippet prints the line pos is in, marking pos with a line of marker characters
+// followed by msg. If the line is the last line of code that was just entered into the
+// interpreter only the marker line is printed, under the line the user typed.
+// It returns false if pos is not a valid position.
+func printSnippet(pos trex.Position, code string, marker rune, msg string) bool {
+	whiteBold := color.New(color.FgWhite).Add(color.Bold).FprintfFunc()
+	redBold := color.New(color.FgRed).Add(color.Bold).FprintfFunc()
+	src := pos.Source()
+	if src == nil || pos.Line() <= 0 || pos.Line() > strings.Count(src.Code, "\n")+1 {
+		return false
+	}
+	line := src.Line(pos.Line())
+	if src.Name == "" && src.Code == code && pos.Line() == strings.Count(code, "\n") {
+		// the error is in the line which was just entered to the interpreter
+		print("    ")
+	} else {
+		redBold(os.Stderr, " --> ")
+		if src.Name != "" {
+			whiteBold(os.Stderr, "%s:", src.Name)
+		}
+		whiteBold(os.Stderr, "line %d\n", pos.Line())
+		redBold(os.Stderr, "  | \n  | ")
+		println(line)
+		redBold(os.Stderr, "  | ")
+	}
+	for i := 0; i < pos.Start() && i < len(line); i++ {
+		if line[i] == '\t' {
+			print("\t")
+		} else {
+			print(" ")
+		}
+	}
+	for i := pos.Start(); i < pos.End(); i++ {
+		redBold(os.Stderr, "%c", marker)
+	}
+	if msg != "" {
+		redBold(os.Stderr, " %s", msg)
+	}
+	println()
+	return true
+}
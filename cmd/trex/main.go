@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"gitlab.com/QazmoQwerty/go-liner-highlight"
+	"gitlab.com/QazmoQwerty/trex"
+)
+
+const gitlabLink = "gitlab.com/QazmoQwerty/trex"
+
+var globals struct {
+	liner                      *liner.State
+	engine                     *trex.Engine
+	forceInterpret             bool
+	perLine                    bool
+	interpreterSyntaxHighlight bool
+	errorColor                 *color.Color
+	outputColor                *color.Color
+}
+
+func main() {
+	args := os.Args[1:]
+
+	input := ""
+	fileNames := []string{}
+	codes := []string{}
+	globals.engine = trex.NewEngine()
+	globals.errorColor = color.New(color.FgHiRed)
+	globals.outputColor = color.New()
+	globals.interpreterSyntaxHighlight = false
+	globals.forceInterpret = false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) > 1 && arg[0] == '-' {
+			switch arg {
+			case "-h":
+				println(`Usage: trex <input> <files> [flags]
+	input: Either a file, text inside square brackets [], or "-" to read the input from stdin.
+	       If it is omitted and stdin is not a terminal, the input is read from stdin.
+	files: Files to be run. If no files are specified trex will run in interpreter mode.
+	flags:
+		-h (show this message)
+		-e <code> (run code, can be given several times. Implies no interpreter mode)
+		-n (run the code once for every line of the input, with the line as its argument)
+		-i (run interpreter after code files have ben executed)
+		-v (show version)
+		-hl (turn on syntax highlighting in the interpreter)
+		-depth <n> (allow at most n calls inside one another, 10000 by default)
+
+	debug flags:
+		-lex (show output of the lexer)
+		-ast (show output of the parser)`)
+				ioExit()
+			case "-ast":
+				globals.engine.ShowAst = true
+			case "-lex":
+				globals.engine.ShowLex = true
+			case "-hl":
+				globals.interpreterSyntaxHighlight = true
+			case "-i":
+				globals.forceInterpret = true
+			case "-n":
+				globals.perLine = true
+			case "-e":
+				i++
+				if i == len(args) {
+					globals.errorColor.Fprint(os.Stderr, "Error:")
+					println(" missing code after \"-e\".")
+					println("Try \"trex -h\" for more information.")
+					ioExit()
+				}
+				codes = append(codes, args[i])
+			case "-depth":
+				i++
+				depth := 0
+				if i < len(args) {
+					depth, _ = strconv.Atoi(args[i])
+				}
+				if depth <= 0 {
+					globals.errorColor.Fprint(os.Stderr, "Error:")
+					println(" \"-depth\" must be followed by a positive number.")
+					println("Try \"trex -h\" for more information.")
+					ioExit()
+				}
+				globals.engine.MaxDepth = depth
+			case "-v":
+				fmt.Printf("Trex %s (%s)\n", trex.Version, gitlabLink)
+				ioExit()
+			default:
+				globals.errorColor.Fprint(os.Stderr, "Error:")
+				println(" Unknown flag \"" + arg + "\".")
+				println("Usage: trex <input> <files> [arguments]")
+				println("Try \"trex -h\" for more information.")
+				ioExit()
+			}
+		} else {
+			if input != "" {
+				fileNames = append(fileNames, arg)
+			} else {
+				input = arg
+			}
+		}
+	}
+
+	defer ioExit()
+
+	if globals.interpreterSyntaxHighlight {
+		globals.outputColor = color.New(color.FgHiBlack)
+	}
+
+	if input == "" && !stdinIsTerminal() {
+		input = "-"
+	}
+	if input == "" {
+		globals.errorColor.Fprint(os.Stderr, "Error:")
+		println(" missing input string")
+		println("Try \"trex -h\" for more information.")
+		ioExit()
+		return
+	}
+	if globals.perLine {
+		if len(fileNames)+len(codes) != 1 || globals.forceInterpret {
+			globals.errorColor.Fprint(os.Stderr, "Error:")
+			println(" \"-n\" requires exactly one file or \"-e\" code, and cannot be used with \"-i\"")
+			println("Try \"trex -h\" for more information.")
+			ioExit()
+		}
+		interpretPerLine(input, fileNames, codes)
+		return
+	}
+	useInterpreter := globals.forceInterpret || (len(fileNames) == 0 && len(codes) == 0)
+	if input == "-" && useInterpreter {
+		globals.errorColor.Fprint(os.Stderr, "Error:")
+		println(" the interpreter cannot be used when the input is read from stdin")
+		println("Try \"trex -h\" for more information.")
+		ioExit()
+		return
+	}
+	input, err := readInput(input)
+	if err != nil {
+		globals.errorColor.Fprint(os.Stderr, "Error:")
+		println(" " + err.Error())
+		ioExit()
+	}
+
+	for _, f := range fileNames {
+		interpretFile(input, f)
+	}
+	for _, code := range codes {
+		runCode("", code, input)
+	}
+	if useInterpreter {
+		startInterpreter(input)
+	}
+}
+
+// readInput returns the input specified by arg, which is either text inside square
+// brackets, "-" for the standard input, or the name of a file.
+func readInput(arg string) (string, error) {
+	if arg[0] == '[' && arg[len(arg)-1] == ']' {
+		return arg[1 : len(arg)-1], nil
+	}
+	if arg == "-" {
+		content, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("could not read stdin: %s", err)
+		}
+		return string(content), nil
+	}
+	content, err := ioutil.ReadFile(arg)
+	if err != nil {
+		return "", fmt.Errorf("could not open file \"%s\"", arg)
+	}
+	return string(content), nil
+}
+
+// openInput is like readInput, except that it does not read the whole input up front.
+func openInput(arg string) (io.Reader, error) {
+	if arg[0] == '[' && arg[len(arg)-1] == ']' {
+		return strings.NewReader(arg[1 : len(arg)-1]), nil
+	}
+	if arg == "-" {
+		return os.Stdin, nil
+	}
+	f, err := os.Open(arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file \"%s\"", arg)
+	}
+	return f, nil
+}
+
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+func interpretFile(input string, file string) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		globals.errorColor.Fprint(os.Stderr, "Error:")
+		println(" could not open file \"" + file + "\"")
+		ioExit()
+	}
+	runCode(file, string(content), input)
+}
+
+func interpretPerLine(input string, fileNames []string, codes []string) {
+	r, err := openInput(input)
+	if err != nil {
+		globals.errorColor.Fprint(os.Stderr, "Error:")
+		println(" " + err.Error())
+		ioExit()
+	}
+	file, code := "", ""
+	if len(fileNames) == 1 {
+		file = fileNames[0]
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			globals.errorColor.Fprint(os.Stderr, "Error:")
+			println(" could not open file \"" + file + "\"")
+			ioExit()
+		}
+		code = string(content)
+	} else {
+		code = codes[0]
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	err = globals.engine.ExecLines(file, code, r, func(val trex.Value, err error) {
+		if err != nil {
+			out.Flush()
+			printError(err, code)
+		} else {
+			globals.outputColor.Fprintln(out, val.String())
+		}
+	})
+	if err != nil {
+		out.Flush()
+		printError(err, code)
+	}
+}
+
+func startInterpreter(input string) {
+	ioSetup()
+	fmt.Printf("Trex %s (%s)\n", trex.Version, gitlabLink)
+	fmt.Printf("Type \"help\" for help, \"exit\" to exit.\n")
+	globals.liner.AppendHistory("exit")
+	globals.liner.AppendHistory("help")
+	for true {
+		code := readCode()
+		if code == "" {
+			continue
+		}
+		runCode("", code, input)
+		for _, name := range globals.engine.Names() {
+			globals.liner.RegisterFunction(name)
+		}
+	}
+}
+
+func runCode(file string, code string, input string) {
+	err := globals.engine.Exec(file, code, input, func(val trex.Value, err error) {
+		if err != nil {
+			printError(err, code)
+		} else {
+			globals.outputColor.Println(val.String())
+		}
+	})
+	if err != nil {
+		printError(err, code)
+	}
+}
@@ -0,0 +1,113 @@
+package trex
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// readCSV parses str as rows of fields separated by sep, and returns a list of the rows,
+// each of which is a list of its fields. If header is set, the first row names the
+// columns and every other row is returned as a map from the column names to its fields.
+func readCSV(env *Environment, str string, sep rune, header bool, pos Position) ListValue {
+	r := csv.NewReader(strings.NewReader(str))
+	r.Comma = sep
+	r.FieldsPerRecord = -1
+	if header {
+		// every row must have a field for every column
+		r.FieldsPerRecord = 0
+	}
+	r.ReuseRecord = true
+	var columns []string
+	ret := ListValue{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return ret
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				panic(newErr(E_CONVERSION, "invalid CSV on line "+strconv.Itoa(parseErr.Line)+": "+parseErr.Err.Error(), pos))
+			}
+			panic(newErr(E_CONVERSION, "invalid CSV: "+err.Error(), pos))
+		}
+		env.step(pos)
+		if header && columns == nil {
+			columns = append([]string{}, record...)
+			continue
+		}
+		if header {
+			row := newMap()
+			for i, field := range record {
+				row.set(columns[i], StringValue{field})
+			}
+			ret.vals = append(ret.vals, row)
+		} else {
+			row := ListValue{make([]Value, len(record))}
+			for i, field := range record {
+				row.vals[i] = StringValue{field}
+			}
+			ret.vals = append(ret.vals, row)
+		}
+		env.checkListLen(len(ret.vals), pos)
+	}
+}
+
+// writeCSV returns rows as CSV with fields separated by sep, quoting the fields which
+// need it. A row is either a list of fields or a map from column names to fields. If
+// the first row is a map its keys are written as a header, and the other rows give the
+// fields for those columns.
+func writeCSV(rows ListValue, sep rune, pos Position) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = sep
+	var columns []string
+	for i, row := range rows.vals {
+		var record []string
+		if m, ok := row.(MapValue); ok {
+			if i == 0 {
+				columns = m.keys
+				if err := w.Write(columns); err != nil {
+					panic(newErr(E_CONVERSION, "cannot write CSV: "+err.Error(), pos))
+				}
+			}
+			if columns == nil {
+				panic(newErr(E_WRONG_TYPE, "expected a list of fields, as the first row is one", pos))
+			}
+			for _, col := range columns {
+				field := ""
+				if val, ok := m.vals[col]; ok {
+					field = val.String()
+				}
+				record = append(record, field)
+			}
+		} else {
+			if columns != nil {
+				panic(newErr(E_WRONG_TYPE, "expected a map, as the first row is one", pos))
+			}
+			for _, field := range valAsList(row).vals {
+				record = append(record, field.String())
+			}
+		}
+		if err := w.Write(record); err != nil {
+			panic(newErr(E_CONVERSION, "cannot write CSV: "+err.Error(), pos))
+		}
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// csvSeparator returns the separator which val gives for CSV, which must be a single
+// character.
+func csvSeparator(val Value, pos Position) rune {
+	str := val.String()
+	sep, size := utf8.DecodeRuneInString(str)
+	if size == 0 || size != len(str) || sep == '"' || sep == '\r' || sep == '\n' {
+		panic(newErr(E_CONVERSION, "the separator of CSV must be a single character other than a quote or a newline", pos))
+	}
+	return sep
+}
@@ -0,0 +1,79 @@
+package trex
+
+import "testing"
+
+func TestCSVRoundTrip(t *testing.T) {
+	tests := []struct {
+		csv    string
+		sep    rune
+		header bool
+		rows   string
+		// out is the CSV which the rows are written as, if it isn't csv
+		out string
+	}{
+		{"a,b,c\n1,2,3", ',', false, "(a, b, c), (1, 2, 3)", ""},
+		{`"a,b",c`, ',', false, "(a,b, c)", ""},
+		{`"say ""hi""",x`, ',', false, `(say "hi", x)`, ""},
+		{"\"two\nlines\",x", ',', false, "(two\nlines, x)", ""},
+		{`a,,""`, ',', false, "(a, , )", "a,,"},
+		{" a , b ", ',', false, "( a ,  b )", `" a "," b "`},
+		{"a;\"b;c\"", ';', false, "(a, b;c)", ""},
+		{"a\t\"b\tc\"\td,e", '\t', false, "(a, b\tc, d,e)", ""},
+		{"name,age\nann,30\n\"bob, jr\",5", ',', true, "{name: ann, age: 30}, {name: bob, jr, age: 5}", ""},
+		{"a,b\n1", ',', false, "(a, b), 1", ""},
+	}
+	for _, test := range tests {
+		rows := readCSV(newEnvironment(), test.csv, test.sep, test.header, Position{})
+		if got := rowsString(rows); got != test.rows {
+			t.Errorf("readCSV(%q) = %s, want %s", test.csv, got, test.rows)
+		}
+		out := test.out
+		if out == "" {
+			out = test.csv
+		}
+		written := writeCSV(rows, test.sep, Position{})
+		if written != out {
+			t.Errorf("writeCSV(readCSV(%q)) = %q, want %q", test.csv, written, out)
+		}
+		if got := rowsString(readCSV(newEnvironment(), written, test.sep, test.header, Position{})); got != test.rows {
+			t.Errorf("readCSV(%q) = %s, want %s", written, got, test.rows)
+		}
+	}
+}
+
+// rowsString returns rows with the fields of each list row in parentheses, so that
+// the rows can be told apart.
+func rowsString(rows ListValue) string {
+	str := ""
+	for i, row := range rows.vals {
+		if i > 0 {
+			str += ", "
+		}
+		if l, ok := row.(ListValue); ok && len(l.vals) > 1 {
+			str += "(" + l.String() + ")"
+		} else {
+			str += row.String()
+		}
+	}
+	return str
+}
+
+func TestCSVErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want ErrorCode
+	}{
+		{`csv 'a,"b'`, E_CONVERSION},
+		{`csv 'a,b"c"'`, E_CONVERSION},
+		{"csv(true) 'a,b\n1'", E_CONVERSION},
+		{`fromcsv('ab') 'a'`, E_CONVERSION},
+		{`fromcsv('"') 'a'`, E_CONVERSION},
+		{`tocsv (({a: 1}), (1, 2))`, E_WRONG_TYPE},
+	}
+	for _, test := range tests {
+		_, err := NewEngine().Eval(test.code, "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != test.want {
+			t.Errorf("%q returned %v, want an error with code %v", test.code, err, test.want)
+		}
+	}
+}
@@ -2,7 +2,7 @@ import os
 
 inputfile = "docs/docs.txt"
 mdfile = "docs/builtin-defs.md"
-gofile = "help.go"
+gofile = "cmd/trex/help.go"
 txtfile = "docs/docs.txt"
 
 items = []
@@ -85,28 +85,28 @@ examplefunc = """func showExample(cmd string) {
 	case "":
 		globals.outputColor.Println(`Try "example xxx" to see an example for a particular subject.`)
 	case "example":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 --> example example
 [Do you really need to see this?]
 `)
 	case "help":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 --> help example
 [help for for how to use the example command]
 `)
 	case "quit":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 --> quit
 [trex will exit]
 `)
 	case "exit":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 --> exit
 [trex will exit]
 `)
 """
 for i in items:
-    examplefunc += '\tcase "' + i.name + '":\n\t\tglobals.outputColor.Println(`\n' + i.example + '\n`)\n'
+    examplefunc += '\tcase "' + i.name + '":\n\t\tprintDoc(`\n' + i.example + '\n`)\n'
 examplefunc += "\t}\n}\n"
 
 helpfunc = """func showHelp(cmd string) {
@@ -125,14 +125,14 @@ Or read the Language Specification: gitlab.com/QazmoQwerty/trex/-/blob/master/do
 	case "help":
 		globals.outputColor.Println(`Use "help xxx" to see help for a particular subject.`)
 	case "exit":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 "exit":
 Exits the interpreter. Identical to "quit".
 Input: none
 Parameters: none
 `)
 	case "quit":
-		globals.outputColor.Println(`
+		globals.outputColor.Print(`
 "quit":
 Exits the interpreter. Identical to "exit".
 Input: a list
@@ -140,11 +140,19 @@ Parameters: none
 `)
 """
 for i in items:
-    helpfunc += '\tcase "' + i.name + '":\n\t\tglobals.outputColor.Println(`\n' + \
+    helpfunc += '\tcase "' + i.name + '":\n\t\tprintDoc(`\n' + \
                 '"' + i.name + '":\n' + i.explanation + \
                 '\nTip: try "example ' + i.name + '" to see an example.' + '\n`)\n'
 helpfunc += "\t}\n}\n"
 
+printfunc = """// printDoc prints documentation of a built-in definition. Documentation may contain
+// '%' (as time layouts do), so it is not passed straight to Print where it would look
+// like a format string.
+func printDoc(doc string) {
+	globals.outputColor.Print(doc)
+}
+"""
+
 with open(gofile, 'w+') as out:
-    out.write("package main\n\n// generated by " + os.path.basename(__file__) + "\n\n" + helpfunc + "\n" + examplefunc)
+    out.write("package main\n\n// generated by " + os.path.basename(__file__) + "\n\n" + helpfunc + "\n" + examplefunc + "\n" + printfunc)
 print('outputted go file "' + gofile + '"!')
\ No newline at end of file
@@ -0,0 +1,442 @@
+package trex
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disiqueira/gotree"
+)
+
+// Engine runs Trex code. Definitions made by code that was run in an engine stay
+// available to all code that is run in it later on, but are not seen by other engines.
+// Separate engines may be used concurrently, a single engine may not.
+// The zero Engine is ready to use.
+type Engine struct {
+	// ShowLex and ShowAst print the output of the lexer and the parser
+	// for all code run by the engine. They are meant for debugging.
+	ShowLex bool
+	ShowAst bool
+
+	// MaxDepth is the number of calls which may be made inside one another before
+	// a call fails. Calls which a definition makes to itself as the last thing it
+	// does don't count towards it. Zero means DefaultMaxDepth, and a negative value
+	// means there is no limit, in which case deep recursion may crash the program.
+	MaxDepth int
+
+	// MaxSteps is the number of steps which code may take each time it is run by the
+	// engine, where a step is a call or the computation of a value of a range or a
+	// comprehension. MaxListLen and MaxStringLen are the maximum number of values in
+	// a list and the maximum number of bytes in a string. Code which exceeds one of
+	// these limits fails with an error whose type is ERR_LIMIT. Limits which are
+	// zero, as they are by default, are not enforced.
+	MaxSteps     int
+	MaxListLen   int
+	MaxStringLen int
+
+	env *Environment
+}
+
+// DefaultMaxDepth is the default value of Engine.MaxDepth.
+const DefaultMaxDepth = 10000
+
+// Builtin is a definition implemented in Go. It gets the argument it was called
+// with and the parameters that were passed to it.
+type Builtin func(input Value, params []Value) (Value, error)
+
+// NewEngine returns an engine with no definitions other than Trex's built-in ones.
+func NewEngine() *Engine {
+	return &Engine{MaxDepth: DefaultMaxDepth, env: newEnvironment()}
+}
+
+// NewString returns a string value.
+func NewString(str string) Value {
+	return StringValue{str}
+}
+
+// NewList returns a list value holding vals.
+func NewList(vals ...Value) Value {
+	return ListValue{vals}
+}
+
+// NewBool returns a boolean value.
+func NewBool(b bool) Value {
+	return BoolValue{b}
+}
+
+// Bool returns the boolean held in the value.
+func (this BoolValue) Bool() bool {
+	return this.val
+}
+
+// Values returns the values held in the list.
+func (this ListValue) Values() []Value {
+	return this.vals
+}
+
+// Keys returns the keys of the map, in the order they were added in.
+func (this MapValue) Keys() []string {
+	return this.keys
+}
+
+// Get returns the value of key in the map, and whether the map contains it.
+func (this MapValue) Get(key string) (Value, bool) {
+	val, ok := this.vals[key]
+	return val, ok
+}
+
+// Define makes fn callable by name from all code run in the engine.
+// An error returned by fn is reported at the position of the call. The lists and maps
+// given to fn are copies, which it may change freely.
+// Define fails if name is the name of one of Trex's built-in definitions or values,
+// which cannot be replaced.
+func (e *Engine) Define(name string, fn Builtin) error {
+	if isPredeclared(name) {
+		return errors.New("cannot define \"" + name + "\": it is a built-in definition")
+	}
+	e.environment().builtins.values[name] = PredeclaredDefinitionValue{func(env *Environment, input Value, params ListValue, pos Position) Value {
+		// fn only sees the exported kinds of values, so sequences are turned into lists
+		val, err := fn(force(input), force(params).(ListValue).vals)
+		if err != nil {
+			panic(newErr(E_BUILTIN, err.Error(), pos))
+		}
+		if val == nil {
+			return NullValue{}
+		}
+		return val
+	}, name}
+	return nil
+}
+
+func isPredeclared(name string) bool {
+	if _, ok := predeclaredFuncs[name]; ok {
+		return true
+	}
+	_, ok := predeclaredValues[name]
+	return ok
+}
+
+// Builtins returns the names of all of Trex's built-in definitions and values.
+func Builtins() []string {
+	names := []string{}
+	for k := range predeclaredFuncs {
+		names = append(names, k)
+	}
+	for k := range predeclaredValues {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names returns the names of all top-level definitions in the engine, including those
+// made by Define.
+func (e *Engine) Names() []string {
+	names := []string{}
+	global := e.environment().global()
+	for k := range global.definitions {
+		names = append(names, k)
+	}
+	for k := range global.values {
+		names = append(names, k)
+	}
+	// definitions made by Define, unless the code run in the engine has replaced them
+	for k := range e.env.builtins.values {
+		_, isDef := global.definitions[k]
+		_, isVal := global.values[k]
+		if !isDef && !isVal {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval runs code with input as its argument and returns its output.
+// Like a Trex program, the output of code with several lines which output values
+// is a string holding each of those values on a line of its own.
+// Eval stops at the first error.
+func (e *Engine) Eval(code, input string) (Value, error) {
+	return e.EvalContext(context.Background(), code, input)
+}
+
+// EvalContext is like Eval, but stops running code with an error once ctx is done.
+func (e *Engine) EvalContext(ctx context.Context, code, input string) (Value, error) {
+	e.start(ctx)
+	prog, err := e.parse(&Source{"", code})
+	if err != nil {
+		return nil, err
+	}
+	outputs := []Value{}
+	for _, n := range prog.lines {
+		val, err := runLine(e.env, n, StringValue{input})
+		if err != nil {
+			return nil, err
+		}
+		switch val.(type) {
+		case NullValue:
+			break
+		default:
+			outputs = append(outputs, val)
+		}
+	}
+	switch len(outputs) {
+	case 0:
+		return NullValue{}, nil
+	case 1:
+		return outputs[0], nil
+	}
+	strs := make([]string, len(outputs))
+	for i, val := range outputs {
+		strs[i] = val.String()
+	}
+	return StringValue{strings.Join(strs, "\n")}, nil
+}
+
+// Exec runs the code of the file name (which may be empty) the way the trex command
+// runs its script files: each line is run separately with input as its argument, and
+// emit is called for every line that isn't a definition or an import with either its
+// output or the error that stopped it, and for every definition or import which failed.
+// An error while running a line does not stop the lines after it.
+// Exec only returns an error if the code could not be parsed, in which case nothing is run.
+func (e *Engine) Exec(name, code, input string, emit func(Value, error)) error {
+	return e.ExecContext(context.Background(), name, code, input, emit)
+}
+
+// ExecContext is like Exec, but stops running code with an error once ctx is done.
+func (e *Engine) ExecContext(ctx context.Context, name, code, input string, emit func(Value, error)) error {
+	e.start(ctx)
+	prog, err := e.parse(&Source{name, code})
+	if err != nil {
+		return err
+	}
+	for _, n := range prog.lines {
+		val, err := runLine(e.env, n, StringValue{input})
+		switch n.(type) {
+		case Definition, Import:
+			if err != nil {
+				emit(nil, err)
+			}
+		default:
+			emit(val, err)
+		}
+	}
+	return nil
+}
+
+// ExecLines runs code once for every line read from r, with the line (without its
+// trailing newline) as its argument, the way awk runs its scripts. While a line is
+// being processed, its number (starting at 1) is available as "linenum", which is
+// removed again once ExecLines returns.
+// If code defines BEGIN or END, they are called before the first line and after
+// the last line respectively. Definitions and imports are only run once, before BEGIN.
+// emit is called with every output that isn't null, and with every error. An error
+// while processing one line does not stop the lines after it.
+// ExecLines returns an error if code could not be parsed or r could not be read.
+func (e *Engine) ExecLines(name, code string, r io.Reader, emit func(Value, error)) error {
+	return e.ExecLinesContext(context.Background(), name, code, r, emit)
+}
+
+// ExecLinesContext is like ExecLines, but stops running code with an error once ctx is
+// done. No more lines are read after that, END is not called, and ctx's error is returned.
+func (e *Engine) ExecLinesContext(ctx context.Context, name, code string, r io.Reader, emit func(Value, error)) error {
+	e.start(ctx)
+	prog, err := e.parse(&Source{name, code})
+	if err != nil {
+		return err
+	}
+	exps := []Node{}
+	for _, n := range prog.lines {
+		switch n.(type) {
+		case Definition, Import:
+			if _, err := runLine(e.env, n, NullValue{}); err != nil {
+				emit(nil, err)
+			}
+		default:
+			exps = append(exps, n)
+		}
+	}
+	run := func(node Node, input Value) {
+		val, err := runLine(e.env, node, input)
+		switch val.(type) {
+		case NullValue:
+			break
+		default:
+			emit(val, err)
+		}
+	}
+	global := e.env.global()
+	callIfDefined := func(name string) {
+		if def, ok := global.definitions[name]; ok {
+			run(FunctionCall{Identifier{name, def.pos}, ExpressionList{}, nil, def.pos}, StringValue{""})
+		}
+	}
+
+	global.values["linenum"] = StringValue{"0"}
+	defer delete(global.values, "linenum")
+	callIfDefined("BEGIN")
+	reader := bufio.NewReader(r)
+	for linenum := 1; ; linenum++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line == "" && err == io.EOF {
+			break
+		}
+		global.values["linenum"] = StringValue{strconv.Itoa(linenum)}
+		for _, n := range exps {
+			run(n, StringValue{strings.TrimSuffix(line, "\n")})
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	callIfDefined("END")
+	return nil
+}
+
+// LoadFile makes the definitions in a Trex file available to all code run in the engine.
+// Lines in the file which aren't definitions or imports are ignored.
+func (e *Engine) LoadFile(path string) error {
+	e.start(context.Background())
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	prog, err := e.parse(&Source{path, string(content)})
+	if err != nil {
+		return err
+	}
+	for _, n := range prog.lines {
+		switch n.(type) {
+		case Definition, Import:
+			if _, err := runLine(e.env, n, NullValue{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Engine) parse(src *Source) (prog Program, err error) {
+	defer recoverer(&err)
+	tokens := TokenQueue{}
+	lexProgram(src, &tokens)
+	if e.ShowLex {
+		for _, tok := range tokens.tokens {
+			showToken(tok)
+		}
+	}
+	if len(tokens.errors) > 0 {
+		return prog, errorList(tokens.errors)
+	}
+	prog = parseProgram(&tokens, TT_EOF)
+	if e.ShowAst {
+		println(printAst(prog).Print())
+	}
+	if len(tokens.errors) > 0 {
+		return prog, errorList(tokens.errors)
+	}
+	return prog, nil
+}
+
+// errorList returns errs as a single error, which is an ErrorList unless there is only one.
+func errorList(errs []myErr) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return ErrorList(errs)
+}
+
+// start prepares the engine's environment for running code, applying the engine's
+// limits and resetting the number of steps which were taken.
+func (e *Engine) start(ctx context.Context) {
+	e.environment()
+	depth := e.MaxDepth
+	if depth == 0 {
+		depth = DefaultMaxDepth
+	}
+	e.env.limits = limits{ctx, depth, e.MaxSteps, e.MaxListLen, e.MaxStringLen}
+	e.env.steps = 0
+}
+
+// environment returns the engine's environment, creating it if the engine was not
+// made by NewEngine.
+func (e *Engine) environment() *Environment {
+	if e.env == nil {
+		e.env = newEnvironment()
+	}
+	return e.env
+}
+
+// runLine runs a single top-level line. If the line fails, all scopes it entered are
+// exited so the environment is left the way it was before the line was run, and the
+// calls which were being made when it failed are recorded in the error. The sequences
+// in the value of the line are computed before it is returned, so that errors which
+// occur while computing them are reported like any other error.
+func runLine(env *Environment, node Node, input Value) (val Value, err error) {
+	scope, calls, builtinParams := env.scope, len(env.calls), env.builtinParams
+	defer func() {
+		if e, ok := err.(myErr); ok && len(env.calls) > calls {
+			e.trace = append([]Frame{}, env.calls[calls:]...)
+			err = e
+		}
+		// an error leaves these as they were where it was raised
+		env.scope = scope
+		env.calls = env.calls[:calls]
+		env.builtinParams = builtinParams
+		env.callParams = nil
+	}()
+	defer recoverer(&err)
+	return force(node.interpret(env, input)), nil
+}
+
+func recoverer(err *error) {
+	if r := recover(); r != nil {
+		switch e := r.(type) {
+		case error:
+			*err = e
+		default:
+			panic(r)
+		}
+	}
+}
+
+func printAst(ast Node) gotree.Tree {
+	if isNil(ast) {
+		return gotree.New("{}")
+	}
+	tree := gotree.New(ast.toString())
+	for _, n := range ast.getChildren() {
+		if n == nil {
+			tree.Add("")
+		} else {
+			tree.AddTree(printAst(n))
+		}
+	}
+	return tree
+}
+
+func isNil(i interface{}) bool {
+	if i == nil {
+		return true
+	}
+	switch reflect.TypeOf(i).Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Array, reflect.Chan, reflect.Slice:
+		return reflect.ValueOf(i).IsNil()
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package trex
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEngineDefine(t *testing.T) {
+	e := NewEngine()
+	shout := func(input Value, params []Value) (Value, error) {
+		return NewString(strings.ToUpper(input.String()) + "!"), nil
+	}
+	fail := func(input Value, params []Value) (Value, error) {
+		return nil, errors.New("failed")
+	}
+	for _, name := range []string{"len", "sort", "true", "null"} {
+		if err := e.Define(name, shout); err == nil {
+			t.Errorf("Define(%q) succeeded, want an error", name)
+		}
+	}
+	if err := e.Define("shout", shout); err != nil {
+		t.Fatalf("Define(%q) failed: %v", "shout", err)
+	}
+	if err := e.Define("fail", fail); err != nil {
+		t.Fatalf("Define(%q) failed: %v", "fail", err)
+	}
+	val, err := e.Eval("shout []", "hi")
+	if err != nil || val.String() != "HI!" {
+		t.Errorf("shout [] = %v, %v, want HI!", val, err)
+	}
+	_, err = e.Eval("fail []", "hi")
+	if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_BUILTIN {
+		t.Errorf("fail [] returned %v, want an error with code %v", err, E_BUILTIN)
+	}
+}
+
+func TestZeroEngine(t *testing.T) {
+	var e Engine
+	if err := e.Define("twice", func(input Value, params []Value) (Value, error) {
+		return NewString(input.String() + input.String()), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	val, err := e.Eval("twice []", "ab")
+	if err != nil || val.String() != "abab" {
+		t.Errorf("twice [] = %v, %v, want abab", val, err)
+	}
+	// the depth of calls is limited as it is by NewEngine
+	_, err = e.Eval("f(x) => 1 + f(x + 1)\nf(0)", "")
+	if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_RECURSION_DEPTH {
+		t.Errorf("unbounded recursion returned %v, want an error with code %v", err, E_RECURSION_DEPTH)
+	}
+	e.MaxDepth = -1
+	val, err = e.Eval("f(x) => 0 if x = 0 else 1 + f(x - 1)\nf(20000)", "")
+	if err != nil || val.String() != "20000" {
+		t.Errorf("f(20000) = %v, %v with no limit, want 20000", val, err)
+	}
+}
+
+func TestEngineErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want ErrorCode
+	}{
+		{"foo", E_UNDEFINED},
+		{"1 / 0", E_DIVISION_BY_ZERO},
+		{"(1, 2)[5]", E_OUT_OF_RANGE},
+		{"len(1, 2) 3", E_PARAM_COUNT},
+		{"f(x) => 1 + f(x + 1)\nf(0)", E_RECURSION_DEPTH},
+		{"f(n) => (x for x in (1, 2)) if n = 0 else (x for x in f(n - 1))\ncount f(5000000)", E_RECURSION_DEPTH},
+		{"(1, 2", E_EXPECTED_TOKEN},
+	}
+	for _, test := range tests {
+		e := NewEngine()
+		e.MaxDepth = 100
+		_, err := e.Eval(test.code, "")
+		errs := Errors(err)
+		if len(errs) == 0 || errs[0].Code() != test.want {
+			t.Errorf("%q returned %v, want an error with code %v", test.code, err, test.want)
+		}
+	}
+}
+
+func TestEngineLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		code  string
+		ctx   context.Context
+		setup func(e *Engine)
+		want  ErrorCode
+	}{
+		{"count (i for i in 0..1000000)", context.Background(), func(e *Engine) { e.MaxSteps = 1000 }, E_STEP_LIMIT},
+		{"count (i for i in 0..1000)", canceled, func(e *Engine) {}, E_CANCELED},
+		{"count (i for i in 0..1000)", context.Background(), func(e *Engine) { e.MaxListLen = 100 }, E_LIST_TOO_LONG},
+		{"len join (\"ab\" for i in 0..1000)", context.Background(), func(e *Engine) { e.MaxStringLen = 100 }, E_STRING_TOO_LONG},
+	}
+	for _, test := range tests {
+		e := NewEngine()
+		test.setup(e)
+		_, err := e.EvalContext(test.ctx, test.code, "")
+		errs := Errors(err)
+		if len(errs) != 1 || errs[0].Code() != test.want || errs[0].Type() != ERR_LIMIT {
+			t.Errorf("%q returned %v, want an error with code %v", test.code, err, test.want)
+		}
+	}
+	// limits apply to each run separately
+	e := NewEngine()
+	e.MaxSteps = 1000
+	for i := 0; i < 3; i++ {
+		if _, err := e.Eval("count (i for i in 0..100)", ""); err != nil {
+			t.Errorf("run %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestEngineDefineInImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lib := filepath.Join(dir, "lib.trex")
+	if err := ioutil.WriteFile(lib, []byte("loud(s) => shout s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine()
+	if err := e.Define("shout", func(input Value, params []Value) (Value, error) {
+		return NewString(strings.ToUpper(input.String()) + "!"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	val, err := e.Eval("import "+strconv.Quote(lib)+" as lib\nlib.loud(\"hi\")", "")
+	if err != nil || val.String() != "HI!" {
+		t.Errorf("lib.loud(\"hi\") = %v, %v, want HI!", val, err)
+	}
+	if names := e.Names(); len(names) != 2 || names[0] != "lib" || names[1] != "shout" {
+		t.Errorf("Names() = %v, want [lib shout]", names)
+	}
+}
+
+func TestEngineDefineForcesSequences(t *testing.T) {
+	e := NewEngine()
+	var got []string
+	if err := e.Define("kinds", func(input Value, params []Value) (Value, error) {
+		got = got[:0]
+		for _, val := range append([]Value{input}, params...) {
+			switch v := val.(type) {
+			case ListValue:
+				got = append(got, "list of "+strconv.Itoa(len(v.Values())))
+			default:
+				got = append(got, val.String())
+			}
+		}
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"kinds (i for i in 0..3)", "list of 3"},
+		{"kinds(words []) lines []", "list of 2, list of 4"},
+		{"kinds(1, 2) (x * 2 for x in (1, 2))", "list of 2, 1, 2"},
+	}
+	for _, test := range tests {
+		if _, err := e.Eval(test.code, "a b c\nd"); err != nil {
+			t.Errorf("%q failed: %v", test.code, err)
+			continue
+		}
+		if s := strings.Join(got, ", "); s != test.want {
+			t.Errorf("%q gave %s, want %s", test.code, s, test.want)
+		}
+	}
+}
+
+func TestExecLines(t *testing.T) {
+	e := NewEngine()
+	var got []string
+	emit := func(val Value, err error) {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		got = append(got, val.String())
+	}
+	code := "BEGIN => 'begin'\nEND => 'end'\nlinenum << ': ' << []"
+	if err := e.ExecLines("", code, strings.NewReader("a\nb\n"), emit); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(got, "|"); s != "begin|1: a|2: b|end" {
+		t.Errorf("ExecLines emitted %s, want begin|1: a|2: b|end", s)
+	}
+	for _, name := range e.Names() {
+		if name == "linenum" {
+			t.Errorf("linenum is still defined after ExecLines returned")
+		}
+	}
+	if _, err := e.Eval("linenum", ""); err == nil {
+		t.Errorf("linenum can still be used after ExecLines returned")
+	}
+}
+
+func TestExecLinesContext(t *testing.T) {
+	e := NewEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := 0
+	emit := func(val Value, err error) {
+		if lines++; lines == 2 {
+			cancel()
+		}
+	}
+	err := e.ExecLinesContext(ctx, "", "END => 'end'\n[]", strings.NewReader("a\nb\nc\nd\n"), emit)
+	if err != context.Canceled {
+		t.Errorf("ExecLinesContext returned %v, want %v", err, context.Canceled)
+	}
+	if lines != 2 {
+		t.Errorf("%d lines were run, want 2", lines)
+	}
+}
+
+func TestEngineRecoversState(t *testing.T) {
+	e := NewEngine()
+	for _, code := range []string{"matches('(') 'a'", "len(1, 2) 3", "f(x) => matches(x) 'a'\nf('[')"} {
+		if _, err := e.Eval(code, ""); err == nil {
+			t.Fatalf("%q succeeded, want an error", code)
+		}
+		if e.env.builtinParams != nil || e.env.callParams != nil || len(e.env.calls) != 0 || e.env.scope != e.env.global() {
+			t.Errorf("%q left the state of the engine changed", code)
+		}
+	}
+}
@@ -1,15 +1,134 @@
-package main
+package trex
+
+import (
+	"fmt"
+	"strings"
+)
 
 type myErr struct {
-	msg string
-	pos Position
-	ty  ErrorType
+	code   ErrorCode
+	msg    string
+	pos    Position
+	labels []Label
+	notes  []string
+	hints  []string
+	trace  []Frame
+}
+
+// Error is the type of the errors returned by Engine when Trex code fails to lex,
+// parse or run. When code has several syntax errors they are all returned together
+// in an ErrorList.
+type Error = myErr
+
+func newErr(code ErrorCode, msg string, pos Position) myErr {
+	return myErr{code, msg, pos, nil, nil, nil, nil}
+}
+
+// withLabel returns err with a label pointing at a position related to it.
+func (err myErr) withLabel(pos Position, msg string) myErr {
+	err.labels = append(err.labels[:len(err.labels):len(err.labels)], Label{pos, msg})
+	return err
+}
+
+// withNote returns err with a note explaining it further.
+func (err myErr) withNote(note string) myErr {
+	err.notes = append(err.notes[:len(err.notes):len(err.notes)], note)
+	return err
+}
+
+// withHint returns err with a suggestion for fixing it.
+func (err myErr) withHint(hint string) myErr {
+	err.hints = append(err.hints[:len(err.hints):len(err.hints)], hint)
+	return err
 }
 
 func (err myErr) Error() string {
+	return err.code.String() + ": " + err.msg
+}
+
+// Code returns the code identifying the kind of the error.
+func (err myErr) Code() ErrorCode {
+	return err.code
+}
+
+// Message returns the description of the error, without its code.
+func (err myErr) Message() string {
 	return err.msg
 }
 
+// Pos returns the position in the code at which the error occurred.
+func (err myErr) Pos() Position {
+	return err.pos
+}
+
+// Type returns the stage at which the error occurred.
+func (err myErr) Type() ErrorType {
+	return err.code.Type()
+}
+
+// Labels returns the secondary positions which are related to the error.
+func (err myErr) Labels() []Label {
+	return err.labels
+}
+
+// Notes returns notes which explain the error further.
+func (err myErr) Notes() []string {
+	return err.notes
+}
+
+// Hints returns suggestions for fixing the error.
+func (err myErr) Hints() []string {
+	return err.hints
+}
+
+// Trace returns the calls which were being made when a runtime error occurred,
+// starting from the outermost one.
+func (err myErr) Trace() []Frame {
+	return err.trace
+}
+
+// Label marks a position which is related to an error, such as the definition
+// which was called with the wrong number of parameters.
+type Label struct {
+	pos Position
+	msg string
+}
+
+// Pos returns the position the label points at.
+func (l Label) Pos() Position {
+	return l.pos
+}
+
+// Message returns the text of the label.
+func (l Label) Message() string {
+	return l.msg
+}
+
+// ErrorList is returned when several errors were found at once, such as when a
+// script has more than one syntax error.
+type ErrorList []Error
+
+func (list ErrorList) Error() string {
+	strs := make([]string, len(list))
+	for i, err := range list {
+		strs[i] = err.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// Errors returns the Trex errors held in err, which is either a single Error or an ErrorList.
+// It returns nil if err is neither.
+func Errors(err error) []Error {
+	switch e := err.(type) {
+	case Error:
+		return []Error{e}
+	case ErrorList:
+		return e
+	default:
+		return nil
+	}
+}
+
 type ErrorType int
 
 const (
@@ -17,4 +136,115 @@ const (
 	ERR_LEXER
 	ERR_PARSER
 	ERR_INTERPRETER
+	ERR_LIMIT
 )
+
+// ErrorCode identifies a kind of error. Codes never change their meaning, so they
+// may be relied upon by tools. Codes below 100 are lexer errors, codes in the 100s
+// are runtime errors, codes in the 200s are syntax errors and codes in the 300s are
+// raised when code exceeds one of the limits of the engine running it.
+type ErrorCode int
+
+const (
+	E_UNKNOWN_CHARACTER ErrorCode = 1
+	E_UNKNOWN_OPERATOR  ErrorCode = 2
+	E_INVALID_ESCAPE    ErrorCode = 3
+
+	E_INTERNAL         ErrorCode = 100
+	E_PARAM_COUNT      ErrorCode = 101
+	E_UNDEFINED        ErrorCode = 102
+	E_NOT_CALLABLE     ErrorCode = 103
+	E_CONVERSION       ErrorCode = 104
+	E_DIVISION_BY_ZERO ErrorCode = 105
+	E_OUT_OF_RANGE     ErrorCode = 106
+	E_KEY_NOT_FOUND    ErrorCode = 107
+	E_INVALID_SLICE    ErrorCode = 108
+	E_WRONG_TYPE       ErrorCode = 109
+	E_BUILTIN          ErrorCode = 110
+	E_IMPORT           ErrorCode = 111
+	E_RECURSION_DEPTH  ErrorCode = 112
+	E_INVALID_PATTERN  ErrorCode = 113
+	E_NUMBER_OVERFLOW  ErrorCode = 114
+
+	E_EXPECTED_TOKEN      ErrorCode = 201
+	E_EXPECTED_EXPRESSION ErrorCode = 202
+	E_EXPECTED_IDENTIFIER ErrorCode = 203
+	E_EXPECTED_FOR_CLAUSE ErrorCode = 204
+	E_EXPECTED_WHITESPACE ErrorCode = 205
+	E_EXPECTED_PATTERN    ErrorCode = 206
+	E_INVALID_REGEX       ErrorCode = 207
+	E_EXPECTED_DEFINITION ErrorCode = 208
+	E_INVALID_PARAMS      ErrorCode = 209
+
+	E_STEP_LIMIT      ErrorCode = 301
+	E_CANCELED        ErrorCode = 302
+	E_LIST_TOO_LONG   ErrorCode = 303
+	E_STRING_TOO_LONG ErrorCode = 304
+)
+
+func (code ErrorCode) String() string {
+	return fmt.Sprintf("E%04d", int(code))
+}
+
+// Type returns the stage at which errors with the code occur.
+func (code ErrorCode) Type() ErrorType {
+	switch {
+	case code < 100:
+		return ERR_LEXER
+	case code < 200:
+		return ERR_INTERPRETER
+	case code < 300:
+		return ERR_PARSER
+	case code < 400:
+		return ERR_LIMIT
+	default:
+		return ERR_GENERAL
+	}
+}
+
+// closestName returns the name in names which is most similar to name, if any of
+// them is similar enough to be what was meant by it.
+func closestName(name string, names []string) (string, bool) {
+	best, bestDist := "", len(name)/3+1
+	for _, n := range names {
+		if n == name {
+			continue
+		}
+		if d := editDistance(name, n); d < bestDist || (d == bestDist && best != "" && n < best) {
+			best, bestDist = n, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -1,4 +1,4 @@
-module trex
+module gitlab.com/QazmoQwerty/trex
 
 go 1.14
 
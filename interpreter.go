@@ -1,4 +1,4 @@
-package main
+package trex
 
 import (
 	"strconv"
@@ -20,12 +20,38 @@ type StringValue struct {
 type NullValue struct {
 }
 
+type BoolValue struct {
+	val bool
+}
+
+// DefinitionValue is a definition along with the scope it was defined in, which its
+// body can see when it is called.
 type DefinitionValue struct {
-	def Definition
+	def   Definition
+	scope *scope
 }
 
 type PredeclaredDefinitionValue struct {
-	fn func(Value, ListValue, Position) Value
+	fn   func(*Environment, Value, ListValue, Position) Value
+	name string
+}
+
+// MapValue maps strings to values. Its keys are kept in the order they were added in.
+type MapValue struct {
+	keys []string
+	vals map[string]Value
+}
+
+func newMap() MapValue {
+	return MapValue{[]string{}, map[string]Value{}}
+}
+
+// set sets the value of key, adding it after the existing keys if it is new.
+func (this *MapValue) set(key string, val Value) {
+	if _, ok := this.vals[key]; !ok {
+		this.keys = append(this.keys, key)
+	}
+	this.vals[key] = val
 }
 
 func (this StringValue) String() string {
@@ -39,7 +65,7 @@ func (this ListValue) String() string {
 			ret += ", "
 		}
 		switch v.(type) {
-		case ListValue:
+		case ListValue, SequenceValue:
 			ret += "(" + v.String() + ")"
 		default:
 			ret += v.String()
@@ -48,6 +74,23 @@ func (this ListValue) String() string {
 	return ret
 }
 
+func (this MapValue) String() string {
+	ret := "{"
+	for i, k := range this.keys {
+		if i != 0 {
+			ret += ", "
+		}
+		ret += k + ": "
+		switch v := this.vals[k].(type) {
+		case ListValue, SequenceValue:
+			ret += "(" + v.String() + ")"
+		default:
+			ret += v.String()
+		}
+	}
+	return ret + "}"
+}
+
 func (this PredeclaredDefinitionValue) String() string {
 	return "<#Definition>"
 }
@@ -56,57 +99,235 @@ func (this NullValue) String() string {
 	return ""
 }
 
+func (this BoolValue) String() string {
+	if this.val {
+		return "true"
+	}
+	return "false"
+}
+
 func (this DefinitionValue) String() string {
 	return "<#Definition>"
 }
 
 func assertParamsNum(expected int, list ListValue, pos Position) {
 	if len(list.vals) != expected {
-		panic(myErr{"incorrect parameter count.\n    have: " + strconv.Itoa(len(list.vals)) +
-			"\n    want: " + strconv.Itoa(expected), pos, ERR_INTERPRETER})
+		panic(paramCountError(len(list.vals), expected, pos))
 	}
 }
 
-func callDefinition(callee Value, input Value, params ListValue, pos Position) Value {
+// assertParamsRange is like assertParamsNum, for definitions which take between low
+// and high parameters.
+func assertParamsRange(low, high int, list ListValue, pos Position) {
+	if n := len(list.vals); n < low || n > high {
+		panic(newErr(E_PARAM_COUNT, "incorrect parameter count.\n    have: "+strconv.Itoa(n)+"\n    want: "+strconv.Itoa(low)+" to "+strconv.Itoa(high), pos))
+	}
+}
+
+func paramCountError(have, want int, pos Position) myErr {
+	return newErr(E_PARAM_COUNT, "incorrect parameter count.\n    have: "+strconv.Itoa(have)+"\n    want: "+strconv.Itoa(want), pos)
+}
+
+func callDefinition(env *Environment, callee Value, input Value, params ListValue, pos Position) Value {
+	exps := env.callParams
+	env.callParams = nil
 	switch def := callee.(type) {
 	case PredeclaredDefinitionValue:
-		return def.fn(input, params, pos)
+		env.step(pos)
+		env.calls = append(env.calls, Frame{def.name, pos})
+		caller := env.builtinParams
+		env.builtinParams = exps
+		ret := env.checkSize(def.fn(env, input, params, pos), pos)
+		env.builtinParams = caller
+		env.calls = env.calls[:len(env.calls)-1]
+		return ret
 	case DefinitionValue:
-		enterBlock()
-		if len(params.vals) != len(def.def.params.identifiers) {
-			panic(myErr{"incorrect parameter count\n    have: " + strconv.Itoa(len(params.vals)) +
-				"\n    want: " + strconv.Itoa(len(def.def.params.identifiers)), pos, ERR_INTERPRETER})
-		}
-		for i := 0; i < len(params.vals); i++ {
-			id := Identifier{def.def.params.identifiers[i].id, def.def.pos}
-			values[len(values)-1][id.id] = params.vals[i]
+		// calls which a definition makes to itself in tail position are made by this
+		// loop rather than by recursing, so that they don't use up the stack. They all
+		// have the same result, which is remembered for each of them.
+		var keys []memoKey
+		for {
+			checkParamCount(def.def, len(params.vals), pos)
+			if def.def.memo {
+				if key, ok := newMemoKey(def, input, params); ok {
+					if ret, ok := env.memos[key]; ok {
+						env.remember(keys, ret)
+						return ret
+					}
+					keys = append(keys, key)
+				}
+			}
+			env.step(pos)
+			env.checkDepth(pos)
+			name := def.def.id.id
+			if name == "" {
+				name = "<anonymous>"
+			}
+			env.calls = append(env.calls, Frame{name, pos})
+			caller := env.scope
+			env.scope = newScope(def.scope)
+			bindParams(env, def.def, input, params)
+			ret, next := interpretTail(env, def.def, def.def.content, input)
+			env.scope = caller
+			env.calls = env.calls[:len(env.calls)-1]
+			if next == nil {
+				env.remember(keys, ret)
+				return ret
+			}
+			def, input, params, pos = next.def, next.input, next.params, next.pos
 		}
-		ret := def.def.content.interpret(input)
-		exitBlock()
-		return ret
 	default:
-		panic(myErr{"cannot call non-definition value", pos, ERR_INTERPRETER})
+		panic(newErr(E_NOT_CALLABLE, "cannot call non-definition value", pos))
 	}
 }
 
-// var definitions = []map[string]Definition{map[string]Definition{}}
-// var values = []map[string]Value{map[string]Value{}}
+// checkParamCount fails if def cannot be called with have parameters.
+func checkParamCount(def Definition, have int, pos Position) {
+	ids := def.params
+	if have == len(ids.identifiers) && !ids.variadic {
+		return
+	}
+	low, high := ids.arity()
+	if have >= low && (high < 0 || have <= high) {
+		return
+	}
+	want := strconv.Itoa(low)
+	if high < 0 {
+		want = "at least " + want
+	} else if high > low {
+		want += " to " + strconv.Itoa(high)
+	}
+	panic(newErr(E_PARAM_COUNT, "incorrect parameter count.\n    have: "+strconv.Itoa(have)+"\n    want: "+want, pos).withLabel(def.pos, "defined here as "+def.signature()))
+}
 
-var definitions = []map[string]Definition{{}}
-var values = []map[string]Value{{}}
+// bindParams binds the parameters of a call to def in the current scope. Parameters
+// which were not given are bound to their default values, which are evaluated in that
+// scope so that they can refer to the parameters before them. The last parameter of a
+// variadic definition is bound to a list of the parameters which were given after the
+// others.
+func bindParams(env *Environment, def Definition, input Value, params ListValue) {
+	ids := def.params
+	for i, id := range ids.identifiers {
+		switch {
+		case ids.variadic && i == len(ids.identifiers)-1:
+			rest := ListValue{}
+			if i < len(params.vals) {
+				rest.vals = params.vals[i:]
+			}
+			env.scope.values[id.id] = rest
+		case i < len(params.vals):
+			env.scope.values[id.id] = params.vals[i]
+		default:
+			env.scope.values[id.id] = ids.defaults[i].interpret(env, input)
+		}
+	}
+}
 
-func (this Program) interpret(input Value) Value {
-	enterBlock()
+// tailCall is a call which a definition made to itself in tail position, and which is
+// yet to be made.
+type tailCall struct {
+	def    DefinitionValue
+	input  Value
+	params ListValue
+	pos    Position
+}
+
+// interpretTail interprets node, which is in tail position in the body of self. If node
+// is a call to self, the call is returned rather than made. Only the branches of
+// conditionals are followed into, as these are where recursive definitions call themselves.
+func interpretTail(env *Environment, self Definition, node Node, input Value) (Value, *tailCall) {
+	switch n := node.(type) {
+	case Program:
+		if len(n.lines) == 1 {
+			env.enterBlock()
+			ret, next := interpretTail(env, self, n.lines[0], input)
+			env.exitBlock()
+			return ret, next
+		}
+	case Conditional:
+		if isTrue(n.condition.interpret(env, input)) {
+			return interpretTail(env, self, n.thenBranch, input)
+		}
+		return interpretTail(env, self, n.elseBranch, input)
+	case FunctionCall:
+		callee := n.callee.interpret(env, input)
+		if def, ok := callee.(DefinitionValue); ok && def.def.pos == self.pos {
+			inputVal, params := n.arguments(env, input)
+			return nil, &tailCall{def, inputVal, params, n.pos}
+		}
+		return n.call(env, input, callee), nil
+	}
+	return node.interpret(env, input), nil
+}
+
+// Environment holds the state of a single interpreter session, so that code run in
+// one environment can never affect code run in another.
+type Environment struct {
+	scope *scope
+	// builtins holds the definitions made by Engine.Define. It is the parent of the
+	// global scope and of the scopes of imported files, so that they can all use them.
+	builtins *scope
+	// calls is the stack of the definitions which are currently being called. It is
+	// not unwound when an error is raised, so that the error can be given a trace.
+	calls   []Frame
+	modules modules
+	limits  limits
+	// steps is the number of steps taken since the engine started running code.
+	steps int
+	// memos holds the results of calls to memoized definitions.
+	memos map[memoKey]Value
+	// callParams are the expressions of the parameters of the call which is about to
+	// be made, and builtinParams are those of the call to the built-in definition which
+	// is running, if they were made by function calls. They let built-in definitions
+	// point at their parameters.
+	callParams    []Expression
+	builtinParams []Expression
+	regexes       *regexCache
+}
+
+// Frame is a call to a definition: the name of the definition and the position of the call.
+type Frame struct {
+	name string
+	pos  Position
+}
+
+// Name returns the name of the called definition, which is "<anonymous>" for anonymous definitions.
+func (f Frame) Name() string {
+	return f.name
+}
+
+// Pos returns the position of the call.
+func (f Frame) Pos() Position {
+	return f.pos
+}
+
+type scope struct {
+	definitions map[string]Definition
+	values      map[string]Value
+	parent      *scope
+}
+
+func newEnvironment() *Environment {
+	builtins := newScope(nil)
+	return &Environment{newScope(builtins), builtins, nil, modules{map[string]ModuleValue{}, nil}, defaultLimits(), 0, nil, nil, nil, nil}
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{map[string]Definition{}, map[string]Value{}, parent}
+}
+
+func (this Program) interpret(env *Environment, input Value) Value {
+	env.enterBlock()
 
 	if len(this.lines) == 1 {
-		val := this.lines[0].interpret(input)
-		exitBlock()
+		val := this.lines[0].interpret(env, input)
+		env.exitBlock()
 		return val
 	}
 
 	ret := StringValue{""}
 	for i, n := range this.lines {
-		s := n.interpret(input)
+		s := n.interpret(env, input)
 
 		switch s.(type) {
 		case NullValue, *NullValue:
@@ -119,115 +340,197 @@ func (this Program) interpret(input Value) Value {
 			}
 		}
 	}
-	exitBlock()
+	env.exitBlock()
 	return ret
 }
 
-func (this Definition) interpret(input Value) Value {
-	definitions[len(definitions)-1][this.id.id] = this
-	if len(definitions) == 1 {
-		globals.liner.RegisterFunction(this.id.id)
+func (this Definition) interpret(env *Environment, input Value) Value {
+	// memoized definitions may call the one which is replaced, so their results
+	// can no longer be trusted
+	if _, ok := env.scope.definitions[this.id.id]; ok {
+		env.memos = nil
 	}
+	env.scope.definitions[this.id.id] = this
 	return NullValue{}
 }
 
-func (this Literal) interpret(input Value) Value {
+func (this Literal) interpret(env *Environment, input Value) Value {
 	return StringValue{this.value}
 }
 
-func (this EmptyExpression) interpret(input Value) Value {
+func (this Template) interpret(env *Environment, input Value) Value {
+	str := ""
+	for _, part := range this.parts {
+		str += part.interpret(env, input).String()
+	}
+	return env.checkSize(StringValue{str}, this.pos)
+}
+
+func (this MapLiteral) interpret(env *Environment, input Value) Value {
+	m := newMap()
+	for i, k := range this.keys {
+		m.set(k.interpret(env, input).String(), this.vals[i].interpret(env, input))
+	}
+	return m
+}
+
+func (this EmptyExpression) interpret(env *Environment, input Value) Value {
 	return NullValue{}
 }
 
-func (this Identifier) interpret(input Value) Value {
+func (this Identifier) interpret(env *Environment, input Value) Value {
 	if fn, ok := predeclaredFuncs[this.id]; ok {
-		return PredeclaredDefinitionValue{fn}
+		return PredeclaredDefinitionValue{fn, this.id}
 	}
-	for i := len(definitions) - 1; i >= 0; i-- {
-		if val, ok := values[i][this.id]; ok {
+	if val, ok := predeclaredValues[this.id]; ok {
+		return val
+	}
+	for s := env.scope; s != nil; s = s.parent {
+		if val, ok := s.values[this.id]; ok {
 			return val
 		}
-		if val, ok := definitions[i][this.id]; ok {
-			return DefinitionValue{val}
+		if val, ok := s.definitions[this.id]; ok {
+			return DefinitionValue{val, s}
+		}
+	}
+	err := newErr(E_UNDEFINED, "undefined identifier \""+this.id+"\"", this.pos)
+	if name, ok := closestName(this.id, env.names()); ok {
+		err = err.withHint("did you mean \"" + name + "\"?")
+	}
+	panic(err)
+}
+
+func createBoolValue(b bool) BoolValue {
+	return BoolValue{b}
+}
+
+// isTrue reports whether val counts as true when used as a condition. Booleans are
+// themselves, null and maps without keys are false, and strings and lists are false
+// only if they are empty (that is, if they are printed as an empty string).
+func isTrue(val Value) bool {
+	switch v := val.(type) {
+	case BoolValue:
+		return v.val
+	case NullValue:
+		return false
+	case MapValue:
+		return len(v.keys) != 0
+	case SequenceValue:
+		// like a list, a sequence is false only if it is printed as an empty string
+		next := v.iterate()
+		first, ok := next()
+		if !ok {
+			return false
 		}
+		_, ok = next()
+		return ok || first.String() != ""
+	default:
+		return v.String() != ""
 	}
-	panic(myErr{"undefined identifier \"" + this.id + "\"", this.pos, ERR_INTERPRETER})
 }
 
-func createBoolValue(b bool) StringValue {
-	if b {
-		return StringValue{"1"}
+// isEmpty reports whether val is null, an empty string, or a list or map with nothing in it.
+func isEmpty(val Value) bool {
+	switch v := val.(type) {
+	case NullValue:
+		return true
+	case StringValue:
+		return v.val == ""
+	case ListValue:
+		return len(v.vals) == 0
+	case MapValue:
+		return len(v.keys) == 0
+	case SequenceValue:
+		_, ok := v.iterate()()
+		return !ok
+	default:
+		return false
 	}
-	return StringValue{""}
+}
+
+// listContains reports whether one of the values of list contains str.
+func listContains(list Value, str string) bool {
+	next := iterAsList(list)
+	for v, ok := next(); ok; v, ok = next() {
+		if strings.Contains(v.String(), str) {
+			return true
+		}
+	}
+	return false
 }
 
 func atoi(str string, pos Position) int {
-	i, err := strconv.ParseInt(str, 0, 32)
-	ret := int(i)
+	i, err := strconv.ParseInt(str, 0, strconv.IntSize)
 	if err != nil {
-		if len(str) > 30 {
-			panic(myErr{strconv.QuoteToGraphic(str[:30]) + `... cannot be converted to a number\n Note: full value was not shown due to length.`, pos, ERR_INTERPRETER})
-		} else {
-			panic(myErr{strconv.QuoteToGraphic(str) + ` cannot be converted to a number`, pos, ERR_INTERPRETER})
-		}
+		panic(conversionError(str, "a whole number", pos))
 	}
-	return ret
+	return int(i)
+}
+
+func (this BinaryOperation) interpret(env *Environment, input Value) Value {
+	return env.checkSize(this.operate(env, input), this.pos)
 }
 
-func (this BinaryOperation) interpret(input Value) Value {
+// operate returns the result of the operation.
+func (this BinaryOperation) operate(env *Environment, input Value) Value {
 
 	switch this.op.ty {
 	case TT_AND:
-		return createBoolValue(this.left.interpret(input).String() != "" && this.right.interpret(input).String() != "")
+		return createBoolValue(isTrue(this.left.interpret(env, input)) && isTrue(this.right.interpret(env, input)))
 	case TT_OR:
-		return createBoolValue(this.left.interpret(input).String() != "" || this.right.interpret(input).String() != "")
+		return createBoolValue(isTrue(this.left.interpret(env, input)) || isTrue(this.right.interpret(env, input)))
 	}
 
-	left := this.left.interpret(input)
-	right := this.right.interpret(input)
+	left := this.left.interpret(env, input)
+	right := this.right.interpret(env, input)
 	leftPos := this.left.getPosition()
 	rightPos := this.right.getPosition()
 
 	switch this.op.ty {
 	case TT_IN:
 		switch r := right.(type) {
-		case ListValue:
-			subStr := left.String()
-			for _, i := range r.vals {
-				if strings.Contains(i.String(), subStr) {
-					return createBoolValue(true)
-				}
-			}
-			return createBoolValue(false)
+		case ListValue, SequenceValue:
+			return createBoolValue(listContains(r, left.String()))
 		case StringValue:
 			return createBoolValue(strings.Contains(right.String(), left.String()))
+		case MapValue:
+			_, ok := r.vals[left.String()]
+			return createBoolValue(ok)
 		default:
 			return createBoolValue(false)
 		}
 	case TT_NOT_IN:
 		switch r := right.(type) {
-		case ListValue:
-			subStr := left.String()
-			for _, i := range r.vals {
-				if strings.Contains(i.String(), subStr) {
-					return createBoolValue(false)
-				}
-			}
-			return createBoolValue(true)
+		case ListValue, SequenceValue:
+			return createBoolValue(!listContains(r, left.String()))
 		case StringValue:
 			return createBoolValue(!strings.Contains(right.String(), left.String()))
+		case MapValue:
+			_, ok := r.vals[left.String()]
+			return createBoolValue(!ok)
 		default:
 			return createBoolValue(true)
 		}
 	case TT_STRING_ADD:
 		return StringValue{left.String() + right.String()}
 	case TT_STRING_MUL:
-		return StringValue{strings.Repeat(left.String(), atoi(right.String(), this.right.getPosition()))}
+		str, n := left.String(), atoi(right.String(), this.right.getPosition())
+		// the length is checked before the string is made, by dividing so that it can't overflow
+		if max := env.limits.maxStringLen; max > 0 && len(str) > 0 && n > max/len(str) {
+			env.checkStringLen(max+1, this.pos)
+		}
+		return StringValue{strings.Repeat(str, n)}
 	case TT_EQUAL:
 		return createBoolValue(left.String() == right.String())
 	case TT_NOT_EQUAL:
 		return createBoolValue(left.String() != right.String())
 	case TT_ADD:
+		if seq, ok := left.(SequenceValue); ok {
+			left = seq.list()
+		}
+		if seq, ok := right.(SequenceValue); ok {
+			right = seq.list()
+		}
 		switch l := left.(type) {
 		case ListValue:
 			switch r := right.(type) {
@@ -266,18 +569,12 @@ func (this BinaryOperation) interpret(input Value) Value {
 		case NullValue:
 			return left
 		}
-		return StringValue{strconv.Itoa(atoi(left.String(), leftPos) + atoi(right.String(), rightPos))}
-	case TT_SUB:
-		return StringValue{strconv.Itoa(atoi(left.String(), leftPos) - atoi(right.String(), rightPos))}
-	case TT_DIV:
-		return StringValue{strconv.Itoa(atoi(left.String(), leftPos) / atoi(right.String(), rightPos))}
-	case TT_MUL:
-		return StringValue{strconv.Itoa(atoi(left.String(), leftPos) * atoi(right.String(), rightPos))}
-	case TT_MOD:
-		return StringValue{strconv.Itoa(atoi(left.String(), leftPos) % atoi(right.String(), rightPos))}
+		return StringValue{calculate(TT_ADD, parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos), rightPos).String()}
+	case TT_SUB, TT_DIV, TT_MUL, TT_MOD:
+		return StringValue{calculate(this.op.ty, parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos), rightPos).String()}
 	case TT_RANGE:
-		low := atoi(left.String(), leftPos)
-		high := atoi(right.String(), rightPos)
+		low := rangeBound(left.String(), leftPos)
+		high := rangeBound(right.String(), rightPos)
 		// if low > high {
 		// 	list := ListValue{make([]Value, low-high)}
 		// 	for i := 0; i < low-high; i++ {
@@ -285,23 +582,16 @@ func (this BinaryOperation) interpret(input Value) Value {
 		// 	}
 		// 	return list
 		// } else {
-		if low >= high {
-			return ListValue{}
-		}
-		list := ListValue{make([]Value, high-low)}
-		for i := 0; i+low < high; i++ {
-			list.vals[i] = StringValue{strconv.Itoa(i + low)}
-		}
-		return list
+		return rangeSequence(env, low, high, this.pos)
 		// }
 	case TT_SMALLER:
-		return createBoolValue(atoi(left.String(), leftPos) < atoi(right.String(), rightPos))
+		return createBoolValue(compareNumbers(parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos)) < 0)
 	case TT_SMALLER_EQUAL:
-		return createBoolValue(atoi(left.String(), leftPos) <= atoi(right.String(), rightPos))
+		return createBoolValue(compareNumbers(parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos)) <= 0)
 	case TT_GREATER:
-		return createBoolValue(atoi(left.String(), leftPos) > atoi(right.String(), rightPos))
+		return createBoolValue(compareNumbers(parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos)) > 0)
 	case TT_GREATER_EQUAL:
-		return createBoolValue(atoi(left.String(), leftPos) >= atoi(right.String(), rightPos))
+		return createBoolValue(compareNumbers(parseNumber(left.String(), leftPos), parseNumber(right.String(), rightPos)) >= 0)
 	case TT_LEXICAL_SMALLER:
 		return createBoolValue(strings.Compare(left.String(), right.String()) < 0)
 	case TT_LEXICAL_SMALLER_EQUAL:
@@ -311,66 +601,101 @@ func (this BinaryOperation) interpret(input Value) Value {
 	case TT_LEXICAL_GREATER_EQUAL:
 		return createBoolValue(strings.Compare(left.String(), right.String()) >= 0)
 	default:
-		panic(myErr{"unimplemented binary operator \"" + this.op.str + "\"", this.pos, ERR_INTERPRETER})
+		panic(newErr(E_INTERNAL, "unimplemented binary operator \""+this.op.str+"\"", this.pos))
 	}
 }
 
-func (this UnaryOperation) interpret(input Value) Value {
-	val := this.expression.interpret(input)
+func (this UnaryOperation) interpret(env *Environment, input Value) Value {
+	val := this.expression.interpret(env, input)
 
 	if this.op.ty == TT_INDIRECTION {
 		return val
 	}
+	if this.op.ty == TT_NOT {
+		return createBoolValue(!isTrue(val))
+	}
 
 	str := val.String()
 
 	switch this.op.ty {
-	case TT_NOT:
-		return createBoolValue(str == "")
 	case TT_ADD:
 		return StringValue{str}
 	case TT_SUB:
-		return StringValue{strconv.Itoa(-atoi(str, this.expression.getPosition()))}
+		return StringValue{parseNumber(str, this.expression.getPosition()).negate().String()}
 	default:
-		panic(myErr{"unimplemented unary operator \"" + this.op.str + "\"", this.pos, ERR_INTERPRETER})
+		panic(newErr(E_INTERNAL, "unimplemented unary operator \""+this.op.str+"\"", this.pos))
 	}
 }
 
-func (this AnonDefinition) interpret(input Value) Value {
+func (this AnonDefinition) interpret(env *Environment, input Value) Value {
 	return DefinitionValue{
 		Definition{
 			Identifier{"", this.pos},
 			this.ids,
 			Program{[]Node{this.exp}, this.pos},
 			this.pos,
+			false,
 		},
+		env.scope,
 	}
 }
 
-func (this Conditional) interpret(input Value) Value {
-	left := this.condition.interpret(input)
-	if left.String() != "" {
-		return this.thenBranch.interpret(input)
+func (this Conditional) interpret(env *Environment, input Value) Value {
+	if isTrue(this.condition.interpret(env, input)) {
+		return this.thenBranch.interpret(env, input)
 	}
-	return this.elseBranch.interpret(input)
+	return this.elseBranch.interpret(env, input)
 }
 
-func enterBlock() {
-	definitions = append(definitions, map[string]Definition{})
-	values = append(values, map[string]Value{})
+// names returns the names of all the definitions and values which can be seen from
+// the current scope, including the built-in ones.
+func (env *Environment) names() []string {
+	names := []string{}
+	for k := range predeclaredFuncs {
+		names = append(names, k)
+	}
+	for k := range predeclaredValues {
+		names = append(names, k)
+	}
+	for s := env.scope; s != nil; s = s.parent {
+		for k := range s.definitions {
+			names = append(names, k)
+		}
+		for k := range s.values {
+			names = append(names, k)
+		}
+	}
+	return names
 }
 
-func exitBlock() {
-	definitions = definitions[:len(definitions)-1]
-	values = values[:len(values)-1]
+// global returns the outermost scope of the environment, or of the imported file which
+// is running.
+func (env *Environment) global() *scope {
+	s := env.scope
+	for s.parent != nil && s.parent != env.builtins {
+		s = s.parent
+	}
+	return s
+}
+
+func (env *Environment) enterBlock() {
+	env.scope = newScope(env.scope)
+}
+
+func (env *Environment) exitBlock() {
+	env.scope = env.scope.parent
 }
 
 func valAsList(val Value) ListValue {
 	switch v := val.(type) {
 	case ListValue:
 		return v
+	case SequenceValue:
+		return v.list()
 	case NullValue:
 		return ListValue{}
+	case MapValue:
+		return ListValue{valToList(v)}
 	default:
 		return ListValue{[]Value{val}}
 	}
@@ -384,120 +709,209 @@ func valToList(arr Value) []Value {
 	case ListValue:
 		list = t.vals
 		break
+	case SequenceValue:
+		list = t.list().vals
 	case StringValue:
 		for _, s := range t.val {
 			list = append(list, StringValue{string(s)})
 		}
 		break
+	case MapValue:
+		for _, k := range t.keys {
+			list = append(list, StringValue{k})
+		}
 	}
 	return list
 }
 
-func (this Comprehension) runComprehension(input Value, idx int, list []Value) ListValue {
-	ret := ListValue{}
-	enterBlock()
-	switch len(this.fors) - idx {
-	case 0:
-		break
-	case 1:
-		for _, v := range list {
-			values[len(values)-1][this.fors[idx].id.id] = v
-			if this.where == nil || this.where.interpret(input).String() != "" {
-				ret.vals = append(ret.vals, this.exp.interpret(input))
+func (this Comprehension) interpret(env *Environment, input Value) Value {
+	return cachedSequence(env, this.pos, this.iterate(env, input, env.scope, 0))
+}
+
+func (this Match) interpret(env *Environment, input Value) Value {
+	subject := input
+	if this.subject != nil {
+		subject = this.subject.interpret(env, input)
+	}
+	for _, arm := range this.arms {
+		env.enterBlock()
+		if arm.pattern.match(env, subject) && (arm.guard == nil || isTrue(arm.guard.interpret(env, input))) {
+			ret := arm.result.interpret(env, input)
+			env.exitBlock()
+			return ret
+		}
+		env.exitBlock()
+	}
+	return NullValue{}
+}
+
+func (this Where) interpret(env *Environment, input Value) Value {
+	env.enterBlock()
+	for _, b := range this.bindings {
+		env.scope.values[b.id.id] = b.value.interpret(env, input)
+	}
+	ret := this.expression.interpret(env, input)
+	env.exitBlock()
+	return ret
+}
+
+// match reports whether val matches the pattern, binding the names in the pattern in
+// the current scope.
+func (this Pattern) match(env *Environment, val Value) bool {
+	switch this.kind {
+	case PAT_WILDCARD:
+		return true
+	case PAT_BIND:
+		env.scope.values[this.value] = val
+		return true
+	case PAT_LITERAL:
+		switch val.(type) {
+		case ListValue, SequenceValue, MapValue:
+			return false
+		}
+		return val.String() == this.value
+	case PAT_REGEX:
+		switch val.(type) {
+		case ListValue, SequenceValue, MapValue:
+			return false
+		}
+		groups := this.re.FindStringSubmatch(val.String())
+		if groups == nil {
+			return false
+		}
+		for i, name := range this.re.SubexpNames() {
+			if name != "" {
+				env.scope.values[name] = StringValue{groups[i]}
 			}
 		}
+		return true
 	default:
-		for _, v := range list {
-			values[len(values)-1][this.fors[idx].id.id] = v
-			ret.vals = append(ret.vals, this.runComprehension(input, idx+1, list).vals...)
+		if seq, ok := val.(SequenceValue); ok {
+			val = seq.list()
+		}
+		list, ok := val.(ListValue)
+		if !ok || len(list.vals) < len(this.elems) || (this.rest == "" && len(list.vals) != len(this.elems)) {
+			return false
+		}
+		for i, elem := range this.elems {
+			if !elem.match(env, list.vals[i]) {
+				return false
+			}
+		}
+		if this.rest != "" && this.rest != "_" {
+			env.scope.values[this.rest] = ListValue{list.vals[len(this.elems):]}
 		}
+		return true
 	}
-	exitBlock()
-	return ret
 }
 
-func (this Comprehension) interpret(input Value) Value {
-	return this.runComprehension(input, 0, valToList(this.fors[0].exp.interpret(input)))
+func (this Pattern) interpret(env *Environment, input Value) Value {
+	panic(newErr(E_INTERNAL, "a pattern cannot be evaluated", this.pos))
 }
 
-func (this ExpressionList) interpret(input Value) Value {
+func (this ExpressionList) interpret(env *Environment, input Value) Value {
 	list := ListValue{}
 	for _, n := range this.expressions {
-		val := n.interpret(input)
+		val := n.interpret(env, input)
 
 		list.vals = append(list.vals, val)
 	}
 	return list
 }
 
-func (this FunctionCall) interpret(input Value) Value {
-	val := this.callee.interpret(input)
-	switch def := val.(type) {
+func (this FunctionCall) interpret(env *Environment, input Value) Value {
+	return this.call(env, input, this.callee.interpret(env, input))
+}
+
+// call calls callee, which is the value of the call's callee, with the call's parameters
+// and argument.
+func (this FunctionCall) call(env *Environment, input Value, callee Value) Value {
+	switch def := callee.(type) {
 	default:
 		if this.arg == nil && len(this.params.expressions) == 0 {
 			return def
 		}
-		panic(myErr{"cannot call non-definition value", this.pos, ERR_INTERPRETER})
-	case PredeclaredDefinitionValue:
-		params := ListValue{}
-		for _, exp := range this.params.expressions {
-			params.vals = append(params.vals, exp.interpret(input))
-		}
-		var inputVal Value
-		if this.arg == nil {
-			inputVal = input
-		} else {
-			inputVal = this.arg.interpret(input)
-		}
-		return def.fn(inputVal, params, this.pos)
-	case DefinitionValue:
-		enterBlock()
-		if len(this.params.expressions) != len(def.def.params.identifiers) {
-			panic(myErr{"incorrect parameter count\n    have: " + strconv.Itoa(len(this.params.expressions)) +
-				"\n    want: " + strconv.Itoa(len(def.def.params.identifiers)), this.pos, ERR_INTERPRETER})
-		}
-		for i := 0; i < len(this.params.expressions); i++ {
-			val := this.params.expressions[i].interpret(input)
-			id := Identifier{def.def.params.identifiers[i].id, def.def.pos}
-			values[len(values)-1][id.id] = val
-		}
-		var inputVal Value
-		if this.arg == nil {
-			inputVal = input
-		} else {
-			inputVal = this.arg.interpret(input)
-		}
-		ret := def.def.content.interpret(inputVal)
-		exitBlock()
-		return ret
+		panic(newErr(E_NOT_CALLABLE, "cannot call non-definition value", this.pos))
+	case PredeclaredDefinitionValue, DefinitionValue:
+		inputVal, params := this.arguments(env, input)
+		env.callParams = this.params.expressions
+		return callDefinition(env, def, inputVal, params, this.pos)
 	}
+}
 
+// arguments evaluates the argument and the parameters of the call.
+func (this FunctionCall) arguments(env *Environment, input Value) (Value, ListValue) {
+	params := ListValue{}
+	for _, exp := range this.params.expressions {
+		params.vals = append(params.vals, exp.interpret(env, input))
+	}
+	if this.arg == nil {
+		return input, params
+	}
+	return this.arg.interpret(env, input), params
 }
 
 func assertInRange(idx, len int, pos Position) {
 	if idx < 0 || idx >= len {
-		panic(myErr{
-			"list index out of range [" + strconv.Itoa(idx) + "] with length " + strconv.Itoa(len),
+		panic(newErr(
+			E_OUT_OF_RANGE,
+			"list index out of range ["+strconv.Itoa(idx)+"] with length "+strconv.Itoa(len),
 			pos,
-			ERR_INTERPRETER,
-		})
+		))
 	}
 }
 
-func (this Subscript) interpret(input Value) Value {
+func (this Subscript) interpret(env *Environment, input Value) Value {
 	var val Value
 	if this.expression == nil {
 		val = input
 	} else {
-		val = this.expression.interpret(input)
+		val = this.expression.interpret(env, input)
 	}
 	if this.idx1 == nil && this.idx2 == nil && this.idx3 == nil {
 		return val
 	}
+	if m, ok := val.(MapValue); ok {
+		if this.idx2 != nil {
+			panic(newErr(E_INVALID_SLICE, "maps cannot be sliced", this.idx1.getPosition()))
+		}
+		key := this.idx1.interpret(env, input).String()
+		if v, ok := m.vals[key]; ok {
+			return v
+		}
+		err := newErr(E_KEY_NOT_FOUND, "key "+strconv.Quote(key)+" not found in map", this.idx1.getPosition())
+		if name, ok := closestName(key, m.keys); ok {
+			err = err.withHint("did you mean " + strconv.Quote(name) + "?")
+		}
+		panic(err)
+	}
+	if seq, ok := val.(SequenceValue); ok {
+		if this.idx2 == nil && this.idx3 == nil {
+			// only compute the values up to the index, unless it counts from the end
+			idx := atoi(this.idx1.interpret(env, input).String(), this.idx1.getPosition())
+			if idx < 0 {
+				vals := seq.list().vals
+				idx += len(vals)
+				assertInRange(idx, len(vals), this.idx1.getPosition())
+				return vals[idx]
+			}
+			next := seq.iterate()
+			for i := 0; ; i++ {
+				v, ok := next()
+				if !ok {
+					assertInRange(idx, i, this.idx1.getPosition())
+				}
+				if i == idx {
+					return v
+				}
+			}
+		}
+		val = seq.list()
+	}
 	vals := valToList(val)
 
 	if this.idx2 == nil && this.idx3 == nil {
-		idx := atoi(this.idx1.interpret(input).String(), this.idx1.getPosition())
+		idx := atoi(this.idx1.interpret(env, input).String(), this.idx1.getPosition())
 		if idx < 0 {
 			idx += len(vals)
 		}
@@ -505,8 +919,8 @@ func (this Subscript) interpret(input Value) Value {
 		return vals[idx]
 	}
 
-	lowStr := this.idx1.interpret(input).String()
-	highStr := this.idx2.interpret(input).String()
+	lowStr := this.idx1.interpret(env, input).String()
+	highStr := this.idx2.interpret(env, input).String()
 	low, high := 0, len(vals)
 	if lowStr != "" {
 		low = atoi(lowStr, this.idx1.getPosition())
@@ -532,14 +946,14 @@ func (this Subscript) interpret(input Value) Value {
 		}
 	}
 
-	stepStr := this.idx3.interpret(input).String()
+	stepStr := this.idx3.interpret(env, input).String()
 	step := 1
 	if stepStr != "" {
 		step = atoi(stepStr, this.idx3.getPosition())
 	}
 
 	if step == 0 {
-		panic(myErr{"slice step index cannot be zero", this.idx3.getPosition(), ERR_INTERPRETER})
+		panic(newErr(E_INVALID_SLICE, "slice step index cannot be zero", this.idx3.getPosition()))
 	}
 	switch t := val.(type) { // TODO - make this more efficient by preallocating memory
 	case ListValue:
@@ -577,13 +991,51 @@ func (this Subscript) interpret(input Value) Value {
 		return newStr
 	}
 
-	panic(myErr{"Third indices are not supported yet.", this.pos, ERR_INTERPRETER})
+	panic(newErr(E_INVALID_SLICE, "Third indices are not supported yet.", this.pos))
+}
+
+// arity returns the least and the most parameters which a definition with these
+// parameters can be called with. The most is -1 if there is no limit.
+func (this IdentifierList) arity() (int, int) {
+	high := len(this.identifiers)
+	low := high
+	if this.variadic {
+		low--
+		high = -1
+	}
+	for _, exp := range this.defaults {
+		if exp != nil {
+			low--
+		}
+	}
+	return low, high
+}
+
+// signature returns how the definition is called, such as "pad(s, width, fill?)"
+// for a definition with a parameter which has a default value.
+func (this Definition) signature() string {
+	params := []string{}
+	for i, id := range this.params.identifiers {
+		switch {
+		case this.params.variadic && i == len(this.params.identifiers)-1:
+			params = append(params, id.id+"...")
+		case this.params.defaults != nil && this.params.defaults[i] != nil:
+			params = append(params, id.id+"?")
+		default:
+			params = append(params, id.id)
+		}
+	}
+	return this.id.id + "(" + strings.Join(params, ", ") + ")"
+}
+
+func (this VariadicParameter) interpret(env *Environment, input Value) Value {
+	panic(newErr(E_INVALID_PARAMS, "\"...\" can only follow the last parameter of a definition", this.pos))
 }
 
-func (this IdentifierList) interpret(input Value) Value {
+func (this IdentifierList) interpret(env *Environment, input Value) Value {
 	list := ListValue{}
 	for _, n := range this.identifiers {
-		list.vals = append(list.vals, n.interpret(input))
+		list.vals = append(list.vals, n.interpret(env, input))
 	}
 	return list
 }
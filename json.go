@@ -0,0 +1,227 @@
+package trex
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxJSONDepth is how deeply arrays and objects may be nested in JSON which is parsed,
+// so that parsing malicious input cannot use up the stack.
+const maxJSONDepth = 1000
+
+// jsonNumber matches the strings which are written as numbers by tojson.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// readJSON parses str as a single JSON value. Objects become maps which keep the order
+// of their keys, arrays become lists, strings and numbers become strings (numbers are
+// kept as they are written), true and false become booleans and null becomes null.
+func readJSON(env *Environment, str string, pos Position) Value {
+	dec := json.NewDecoder(strings.NewReader(str))
+	dec.UseNumber()
+	val := readJSONValue(env, dec, 0, pos)
+	if _, err := dec.Token(); err != io.EOF {
+		panic(newErr(E_CONVERSION, "invalid JSON: unexpected data after the value at offset "+strconv.FormatInt(dec.InputOffset(), 10), pos))
+	}
+	return val
+}
+
+func readJSONValue(env *Environment, dec *json.Decoder, depth int, pos Position) Value {
+	tok, err := dec.Token()
+	if err != nil {
+		panic(jsonError(err, dec, pos))
+	}
+	env.step(pos)
+	switch t := tok.(type) {
+	case json.Delim:
+		if depth == maxJSONDepth {
+			panic(newErr(E_CONVERSION, "invalid JSON: arrays and objects are nested more than "+strconv.Itoa(maxJSONDepth)+" deep", pos))
+		}
+		if t == '[' {
+			ret := ListValue{[]Value{}}
+			for dec.More() {
+				ret.vals = append(ret.vals, readJSONValue(env, dec, depth+1, pos))
+				env.checkListLen(len(ret.vals), pos)
+			}
+			readJSONEnd(dec, pos)
+			return ret
+		}
+		ret := newMap()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				panic(jsonError(err, dec, pos))
+			}
+			ret.set(key.(string), readJSONValue(env, dec, depth+1, pos))
+		}
+		readJSONEnd(dec, pos)
+		return ret
+	case string:
+		return StringValue{t}
+	case json.Number:
+		return StringValue{t.String()}
+	case bool:
+		return BoolValue{t}
+	default:
+		return NullValue{}
+	}
+}
+
+// readJSONEnd reads the end of an array or an object.
+func readJSONEnd(dec *json.Decoder, pos Position) {
+	if _, err := dec.Token(); err != nil {
+		panic(jsonError(err, dec, pos))
+	}
+}
+
+func jsonError(err error, dec *json.Decoder, pos Position) myErr {
+	if err == io.EOF {
+		err = errors.New("unexpected end of JSON input")
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return newErr(E_CONVERSION, "invalid JSON at offset "+strconv.FormatInt(syntaxErr.Offset, 10)+": "+syntaxErr.Error(), pos)
+	}
+	return newErr(E_CONVERSION, "invalid JSON at offset "+strconv.FormatInt(dec.InputOffset(), 10)+": "+err.Error(), pos)
+}
+
+// writeJSON returns val as JSON. Maps become objects, lists and sequences become arrays,
+// booleans become true and false and null becomes null. Strings which are numbers as
+// JSON writes them become numbers, and all other strings become strings.
+func writeJSON(env *Environment, val Value, pos Position) string {
+	var b strings.Builder
+	writeJSONValue(env, &b, val, pos)
+	return b.String()
+}
+
+func writeJSONValue(env *Environment, b *strings.Builder, val Value, pos Position) {
+	switch v := val.(type) {
+	case StringValue:
+		if jsonNumber.MatchString(v.val) {
+			b.WriteString(v.val)
+		} else {
+			str, _ := json.Marshal(v.val)
+			b.Write(str)
+		}
+	case BoolValue:
+		b.WriteString(v.String())
+	case NullValue:
+		b.WriteString("null")
+	case ListValue, SequenceValue:
+		b.WriteByte('[')
+		next := iterToList(v)
+		for e, ok := next(); ok; {
+			writeJSONValue(env, b, e, pos)
+			if e, ok = next(); ok {
+				b.WriteByte(',')
+			}
+		}
+		b.WriteByte(']')
+	case MapValue:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			b.Write(key)
+			b.WriteByte(':')
+			writeJSONValue(env, b, v.vals[k], pos)
+		}
+		b.WriteByte('}')
+	default:
+		panic(newErr(E_WRONG_TYPE, "definitions and modules cannot be turned into JSON", pos))
+	}
+	env.checkStringLen(b.Len(), pos)
+}
+
+// getPath returns the value at path within val, or null if there is none. A path is
+// made of keys of maps, separated by dots, and of indices of lists in square brackets
+// (which count from the end if they are negative), such as "a.b[0]". A key which
+// contains dots or brackets is written in quotes in square brackets, such as "a['b.c']".
+func getPath(val Value, path string, pos Position) Value {
+	for _, step := range parsePath(path, pos) {
+		switch v := val.(type) {
+		case MapValue:
+			if step.isIndex {
+				return NullValue{}
+			}
+			next, ok := v.vals[step.key]
+			if !ok {
+				return NullValue{}
+			}
+			val = next
+		case ListValue, SequenceValue:
+			if !step.isIndex {
+				return NullValue{}
+			}
+			list := valAsList(v)
+			idx := step.idx
+			if idx < 0 {
+				idx += len(list.vals)
+			}
+			if idx < 0 || idx >= len(list.vals) {
+				return NullValue{}
+			}
+			val = list.vals[idx]
+		default:
+			return NullValue{}
+		}
+	}
+	return val
+}
+
+// pathStep is a key of a map or an index of a list in a path.
+type pathStep struct {
+	key     string
+	idx     int
+	isIndex bool
+}
+
+func parsePath(path string, pos Position) []pathStep {
+	invalid := newErr(E_BUILTIN, "invalid path "+strconv.Quote(path), pos)
+	steps := []pathStep{}
+	rest := path
+	for len(steps) == 0 || rest != "" {
+		if rest == "" {
+			panic(invalid)
+		}
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				panic(invalid)
+			}
+			key := rest[1:end]
+			rest = rest[end+1:]
+			if len(key) >= 2 && (key[0] == '\'' || key[0] == '"') && key[len(key)-1] == key[0] {
+				steps = append(steps, pathStep{key[1 : len(key)-1], 0, false})
+				continue
+			}
+			idx, err := strconv.Atoi(key)
+			if err != nil {
+				panic(invalid.withNote("indices of lists must be whole numbers, and keys in brackets must be quoted"))
+			}
+			steps = append(steps, pathStep{"", idx, true})
+			continue
+		}
+		if len(steps) > 0 {
+			if rest[0] != '.' {
+				panic(invalid)
+			}
+			rest = rest[1:]
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			panic(invalid)
+		}
+		steps = append(steps, pathStep{rest[:end], 0, false})
+		rest = rest[end:]
+	}
+	return steps
+}
@@ -0,0 +1,114 @@
+package trex
+
+import "testing"
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		json string
+		// out is the JSON which the value is written as, if it isn't json
+		out string
+	}{
+		{`0`, ""},
+		{`-12`, ""},
+		{`3.25`, ""},
+		{`1e300`, ""},
+		{`-2.5E-3`, ""},
+		{`123456789012345678901234567890`, ""},
+		{`true`, ""},
+		{`false`, ""},
+		{`null`, ""},
+		{`"text"`, ""},
+		{`"12"`, `12`},
+		{`"true"`, ""},
+		{`"null"`, ""},
+		{`"a\"b\\c\né"`, `"a\"b\\c\né"`},
+		{`[]`, ""},
+		{`{}`, ""},
+		{`[1,"a",true,null,[2,[]],{"k":false}]`, ""},
+		{`{"b":1,"a":{"c":[null]},"":""}`, ""},
+		{` { "a" : [ 1 , 2 ] } `, `{"a":[1,2]}`},
+	}
+	for _, test := range tests {
+		env := newEnvironment()
+		out := test.out
+		if out == "" {
+			out = test.json
+		}
+		if got := writeJSON(env, readJSON(env, test.json, Position{}), Position{}); got != out {
+			t.Errorf("tojson fromjson %s = %s, want %s", test.json, got, out)
+		}
+	}
+}
+
+func TestJSONTypes(t *testing.T) {
+	tests := []struct {
+		json string
+		want Value
+	}{
+		{`true`, BoolValue{true}},
+		{`false`, BoolValue{false}},
+		{`null`, NullValue{}},
+		{`1.50`, StringValue{"1.50"}},
+		{`"true"`, StringValue{"true"}},
+	}
+	for _, test := range tests {
+		if got := readJSON(newEnvironment(), test.json, Position{}); got != test.want {
+			t.Errorf("fromjson %s = %#v, want %#v", test.json, got, test.want)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []string{
+		`fromjson '{"a":}'`,
+		`fromjson '[1, 2'`,
+		`fromjson '1 2'`,
+		`fromjson ''`,
+		`fromjson 'nul'`,
+		`fromjson ("[" * 2000)`,
+	}
+	for _, code := range tests {
+		_, err := NewEngine().Eval(code, "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_CONVERSION {
+			t.Errorf("%q returned %v, want an error with code %v", code, err, E_CONVERSION)
+		}
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	const data = `{"a":{"b":[10,{"c":"x"},30]},"d.e":1,"f":null,"g":[[1,2],[3,4]]}`
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a.b[0]", "10"},
+		{"a.b[-1]", "30"},
+		{"a.b[1].c", `"x"`},
+		{"a.b[3]", "null"},
+		{"a.b[-4]", "null"},
+		{"a.x", "null"},
+		{"a.b.c", "null"},
+		{"a[0]", "null"},
+		{"['d.e']", "1"},
+		{`["d.e"]`, "1"},
+		{"f", "null"},
+		{"f.g", "null"},
+		{"g[1][0]", "3"},
+	}
+	val := readJSON(newEnvironment(), data, Position{})
+	for _, test := range tests {
+		got := getPath(val, test.path, Position{})
+		if s := writeJSON(newEnvironment(), got, Position{}); s != test.want {
+			t.Errorf("get(%q) = %s, want %s", test.path, s, test.want)
+		}
+	}
+}
+
+func TestGetInvalidPaths(t *testing.T) {
+	for _, path := range []string{"", ".", "a.", ".a", "a..b", "a[", "a[x]", "a[0", "[0]x"} {
+		_, err := NewEngine().Eval("get('"+path+"') null", "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_BUILTIN {
+			t.Errorf("get(%q) returned %v, want an error with code %v", path, err, E_BUILTIN)
+		}
+	}
+}
@@ -1,87 +1,28 @@
-package main
+package trex
 
-import (
-	"strconv"
-	"strings"
-)
+import "strconv"
 
-func lexLine(tokens *TokenQueue, isFirstLine bool) {
-	prompt := ">>> "
-	if isFirstLine {
-		defer func() {
-			if err := recover(); err != nil {
-				lineCount++
-				switch e := err.(type) {
-				case error:
-					printError(e)
-				default:
-					panic(err)
-				}
-				for tokens.size() > 0 {
-					tokens.next()
-				}
-				tokens.pushBackEOF()
-			}
-		}()
-	} else {
-		prompt = "... "
-	}
-	line := readLine(prompt)
-	if line == "\n" {
-		lineCount++
-		tokens.pushBackEOF()
-		return
-	}
-	if line == "exit\n" || line == "quit\n" {
-		ioExit()
-		return
-	} else if line == "help\n" || strings.HasPrefix(line, "help ") {
-		showHelp(line)
-		lineCount++
-		tokens.pushBackEOF()
-		return
-	} else if line == "example\n" || strings.HasPrefix(line, "example ") {
-		showExample(line)
-		lineCount++
-		tokens.pushBackEOF()
-		return
-	}
-	switch line[len(line)-2] {
-	case '\\':
-		lex(line[:len(line)-2], tokens)
-		lexLine(tokens, false)
-	case '{':
-		lex(line, tokens)
-		for {
-			line := readLine("... ")
-			lex(line, tokens)
-			if line == "}\n" {
-				break
-			}
-		}
-	default:
-		lex(line, tokens)
-	}
-	if isFirstLine {
-		tokens.pushBackEOF()
-	}
+func lexProgram(src *Source, tokens *TokenQueue) {
+	lineCount := lex(src, tokens)
+	tokens.pushBack(Token{TT_EOF, "", Position{lineCount, 0, 0, src}})
 }
 
-func lexProgram(str string, tokens *TokenQueue) {
-	lineCount = 1
-	lex(str, tokens)
-	tokens.pushBack(Token{TT_EOF, "", Position{lineCount, 0, 0}})
+// lex appends the tokens of src to tokens and returns the number of lines in it.
+func lex(src *Source, tokens *TokenQueue) int {
+	return lexAt(src, src.Code, 1, 0, tokens)
 }
 
-var lineCount = 1
-
-func lex(str string, tokens *TokenQueue) {
+// lexAt appends the tokens of code, which is found in src at the given line and column,
+// to tokens and returns the line code ends at.
+func lexAt(src *Source, code string, line int, col int, tokens *TokenQueue) int {
+	lineCount := line
+	str := code
 	runes := []rune(str)
-	pos := 0
+	pos := col
 	idx := 0
 	for idx < len(runes) {
 		outputToken := true
-		tok := Token{CT_ILLEGAL, string(runes[idx]), Position{lineCount, pos, pos + 1}}
+		tok := Token{CT_ILLEGAL, string(runes[idx]), Position{lineCount, pos, pos + 1, src}}
 		curr := runes[idx]
 		idx++
 		pos++
@@ -102,7 +43,7 @@ func lex(str string, tokens *TokenQueue) {
 				lastTy = tokens.peekBack().ty
 			}
 			switch lastTy {
-			case TT_LITERAL, TT_IDENTIFIER, TT_PARENTHESIS_CLOSE, TT_CURLY_BRACES_CLOSE, TT_SQUARE_BRACKETS_CLOSE:
+			case TT_LITERAL, TT_TEMPLATE, TT_IDENTIFIER, TT_PARENTHESIS_CLOSE, TT_CURLY_BRACES_CLOSE, TT_SQUARE_BRACKETS_CLOSE:
 				tok.ty = TT_TERMINATOR
 			default:
 				tok.ty = TT_WHITESPACE
@@ -120,13 +61,42 @@ func lex(str string, tokens *TokenQueue) {
 					idx++
 					pos++
 				}
-				tok.data = strconv.Itoa(atoi(tok.data, tok.pos))
+				tok.data = parseNumber(tok.data, tok.pos).String()
 			} else {
 				for idx < len(runes) && runeType(runes[idx]) == CT_DIGIT {
 					tok.data += string(runes[idx])
 					idx++
 					pos++
 				}
+				// a fraction, but not the start of a range ("1..5")
+				if idx+1 < len(runes) && runes[idx] == '.' && runeType(runes[idx+1]) == CT_DIGIT {
+					tok.data += "."
+					idx++
+					pos++
+					for idx < len(runes) && runeType(runes[idx]) == CT_DIGIT {
+						tok.data += string(runes[idx])
+						idx++
+						pos++
+					}
+				}
+				// an exponent, such as in "1.5e3" or "1e-9"
+				if exp := idx + 1; exp < len(runes) && (runes[idx] == 'e' || runes[idx] == 'E') {
+					if (runes[exp] == '+' || runes[exp] == '-') && exp+1 < len(runes) {
+						exp++
+					}
+					if runeType(runes[exp]) == CT_DIGIT {
+						for idx < exp {
+							tok.data += string(runes[idx])
+							idx++
+							pos++
+						}
+						for idx < len(runes) && runeType(runes[idx]) == CT_DIGIT {
+							tok.data += string(runes[idx])
+							idx++
+							pos++
+						}
+					}
+				}
 			}
 			tok.pos.end = pos
 		case CT_LETTER:
@@ -135,14 +105,7 @@ func lex(str string, tokens *TokenQueue) {
 				idx++
 				pos++
 			}
-			if tok.data == "false" || tok.data == "true" {
-				tok.ty = TT_LITERAL
-				if tok.data == "false" {
-					tok.data = ""
-				} else {
-					tok.data = "1"
-				}
-			} else if isOperator(tok.data) {
+			if isOperator(tok.data) {
 				tok.ty = opType(tok.data)
 				if tok.ty == TT_IN && tokens.peekBack().ty == TT_WHITESPACE && tokens.peekBeforeBack().ty == TT_NOT {
 					tokens.popBack()
@@ -155,7 +118,22 @@ func lex(str string, tokens *TokenQueue) {
 			}
 			tok.pos.end = pos
 		case CT_OPERATOR:
-			for idx < len(runes) && runeType(runes[idx]) == CT_OPERATOR {
+			// take the longest operator the characters start with, so that operators
+			// which start with a shorter one (such as ".<." and ".") are lexed correctly
+			longest := 0
+			op := tok.data
+			for i := idx; i < len(runes) && runeType(runes[i]) == CT_OPERATOR; i++ {
+				op += string(runes[i])
+				if isOperator(op) {
+					longest = i - idx + 1
+				}
+			}
+			if longest > 0 {
+				tok.data += string(runes[idx : idx+longest])
+				idx += longest
+				pos += longest
+			}
+			for idx < len(runes) && runeType(runes[idx]) == CT_OPERATOR && longest == 0 {
 				newOp := tok.data + string(runes[idx])
 				if isOperator(newOp) || !isOperator(tok.data) {
 					tok.data = newOp
@@ -166,10 +144,20 @@ func lex(str string, tokens *TokenQueue) {
 				}
 			}
 			tok.ty = opType(tok.data)
+			// a pipeline may be continued on the next line, as in "words\n|> unique"
+			if tok.ty == TT_PIPE {
+				if tokens.peekBack().ty == TT_WHITESPACE {
+					tokens.popBack()
+				}
+				if back := tokens.peekBack(); back.ty == TT_TERMINATOR && back.pos.line < tok.pos.line {
+					tokens.popBack()
+				}
+			}
 			switch opType(tok.data) {
 			case TT_UNKNOWN:
 				tok.pos.end = pos
-				panic(myErr{"Operator \"" + tok.data + "\" does not exist.", tok.pos, ERR_LEXER})
+				tokens.errors = append(tokens.errors, newErr(E_UNKNOWN_OPERATOR, "Operator \""+tok.data+"\" does not exist.", tok.pos))
+				outputToken = false
 			case TT_SINGLE_QUOTE, TT_DOUBLE_QUOTE, TT_TICK_QUOTE:
 				tok.ty = TT_LITERAL
 				close := rune(tok.data[0])
@@ -186,6 +174,9 @@ func lex(str string, tokens *TokenQueue) {
 				}
 				idx++
 				pos++
+				if close == '`' {
+					tok.ty = TT_TEMPLATE
+				}
 			case TT_SINGLE_LINE_COMMENT:
 				for idx < len(runes) && runes[idx] != '\n' {
 					idx++
@@ -231,7 +222,7 @@ func lex(str string, tokens *TokenQueue) {
 						idx++
 					}
 					tok.pos.end = pos
-					tok.data += string(atoi(str, Position{lineCount, startPos, pos}))
+					tok.data += string(rune(atoi(str, Position{lineCount, startPos, pos, src})))
 					break
 				default:
 					if '0' <= c && c <= '9' {
@@ -242,17 +233,18 @@ func lex(str string, tokens *TokenQueue) {
 							idx++
 						}
 						tok.pos.end = pos
-						tok.data += string(atoi(str, Position{lineCount, startPos, pos}))
+						tok.data += string(rune(atoi(str, Position{lineCount, startPos, pos, src})))
 					} else {
 						tok.pos.end = pos
-						panic(myErr{"Invalid escape sequence.", Position{lineCount, startPos, pos}, ERR_LEXER})
+						tokens.errors = append(tokens.errors, newErr(E_INVALID_ESCAPE, "Invalid escape sequence.", Position{lineCount, startPos, pos, src}))
 					}
 				}
 			}
 
 			break
 		case CT_ILLEGAL:
-			panic(myErr{`unknown character ` + strconv.QuoteRune(curr), tok.pos, ERR_LEXER})
+			tokens.errors = append(tokens.errors, newErr(E_UNKNOWN_CHARACTER, `unknown character `+strconv.QuoteRune(curr), tok.pos))
+			outputToken = false
 		}
 		if pos != 0 {
 			tok.pos.end = pos
@@ -261,4 +253,5 @@ func lex(str string, tokens *TokenQueue) {
 			tokens.pushBack(tok)
 		}
 	}
+	return lineCount
 }
@@ -0,0 +1,70 @@
+package trex
+
+import (
+	"context"
+	"strconv"
+)
+
+// limits restricts the resources which code run in an environment may use. Limits
+// which are zero are not enforced.
+type limits struct {
+	ctx          context.Context
+	maxDepth     int
+	maxSteps     int
+	maxListLen   int
+	maxStringLen int
+}
+
+func defaultLimits() limits {
+	return limits{ctx: context.Background(), maxDepth: DefaultMaxDepth}
+}
+
+// step is called for every call which is made and for every value of a range or a
+// comprehension which is computed. It fails once too many steps were taken, or once
+// the context of the environment is done.
+func (env *Environment) step(pos Position) {
+	env.steps++
+	if env.limits.maxSteps > 0 && env.steps > env.limits.maxSteps {
+		panic(newErr(E_STEP_LIMIT, "the maximum of "+strconv.Itoa(env.limits.maxSteps)+" steps was exceeded", pos))
+	}
+	// checking the context takes a lock, so it is only done every few steps
+	if env.steps%256 == 0 {
+		if err := env.limits.ctx.Err(); err != nil {
+			panic(newErr(E_CANCELED, "execution was stopped: "+err.Error(), pos))
+		}
+	}
+}
+
+// checkDepth fails if a call, or the computation of a value of a sequence which reads
+// another sequence, cannot be made inside the ones which are currently being made.
+func (env *Environment) checkDepth(pos Position) {
+	if env.limits.maxDepth > 0 && len(env.calls) >= env.limits.maxDepth {
+		panic(newErr(E_RECURSION_DEPTH, "maximum recursion depth of "+strconv.Itoa(env.limits.maxDepth)+" exceeded", pos))
+	}
+}
+
+// checkListLen fails if a list of n values is longer than is allowed.
+func (env *Environment) checkListLen(n int, pos Position) {
+	if env.limits.maxListLen > 0 && n > env.limits.maxListLen {
+		panic(newErr(E_LIST_TOO_LONG, "list is longer than the maximum of "+strconv.Itoa(env.limits.maxListLen)+" values", pos))
+	}
+}
+
+// checkStringLen fails if a string of n bytes is longer than is allowed.
+func (env *Environment) checkStringLen(n int, pos Position) {
+	if env.limits.maxStringLen > 0 && n > env.limits.maxStringLen {
+		panic(newErr(E_STRING_TOO_LONG, "string is longer than the maximum of "+strconv.Itoa(env.limits.maxStringLen)+" bytes", pos))
+	}
+}
+
+// checkSize fails if val is a list or a string which is longer than is allowed, and
+// otherwise returns it.
+func (env *Environment) checkSize(val Value, pos Position) Value {
+	switch v := val.(type) {
+	case StringValue:
+		env.checkStringLen(len(v.val), pos)
+	case ListValue:
+		env.checkListLen(len(v.vals), pos)
+	}
+	return val
+}
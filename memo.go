@@ -0,0 +1,85 @@
+package trex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxMemos is the number of results of calls to memoized definitions which are kept.
+// Once there are this many, they are all forgotten, so that memoized definitions
+// which are made inside others, and so get a new scope on every call, cannot use up
+// all the memory.
+const maxMemos = 1 << 16
+
+// memoKey identifies a call to a memoized definition by the definition, the scope it
+// was made in and the argument and parameters it was called with.
+type memoKey struct {
+	def   Position
+	scope *scope
+	args  string
+}
+
+// newMemoKey returns the key of a call to def. Calls which are given definitions or
+// modules cannot be told apart by their values, so false is returned for them and
+// their results are not cached.
+func newMemoKey(def DefinitionValue, input Value, params ListValue) (memoKey, bool) {
+	var b strings.Builder
+	for _, val := range append([]Value{input}, params.vals...) {
+		if !writeMemoKey(&b, val) {
+			return memoKey{}, false
+		}
+	}
+	return memoKey{def.def.pos, def.scope, b.String()}, true
+}
+
+// writeMemoKey writes val to b so that values which differ, even only in their types
+// (such as null and "", or the list ("a", "b") and the string "a, b"), are written
+// differently. It returns false if val is or holds a definition or a module.
+func writeMemoKey(b *strings.Builder, val Value) bool {
+	switch v := val.(type) {
+	case StringValue:
+		b.WriteString(strconv.Quote(v.val))
+	case BoolValue:
+		b.WriteString(v.String())
+	case NullValue:
+		b.WriteString("null")
+	case ListValue:
+		b.WriteByte('(')
+		for _, e := range v.vals {
+			if !writeMemoKey(b, e) {
+				return false
+			}
+			b.WriteByte(',')
+		}
+		b.WriteByte(')')
+	case SequenceValue:
+		return writeMemoKey(b, v.list())
+	case MapValue:
+		b.WriteByte('{')
+		for _, k := range v.keys {
+			b.WriteString(strconv.Quote(k))
+			b.WriteByte(':')
+			if !writeMemoKey(b, v.vals[k]) {
+				return false
+			}
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	default:
+		return false
+	}
+	return true
+}
+
+// remember caches ret as the result of the calls with the given keys.
+func (env *Environment) remember(keys []memoKey, ret Value) {
+	if len(keys) == 0 {
+		return
+	}
+	if env.memos == nil || len(env.memos)+len(keys) > maxMemos {
+		env.memos = map[memoKey]Value{}
+	}
+	for _, key := range keys {
+		env.memos[key] = ret
+	}
+}
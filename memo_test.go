@@ -0,0 +1,68 @@
+package trex
+
+import "testing"
+
+func TestMemoKeys(t *testing.T) {
+	m := newMap()
+	m.set("a", StringValue{"b"})
+	vals := []Value{
+		NullValue{},
+		StringValue{""},
+		StringValue{"null"},
+		BoolValue{true},
+		StringValue{"true"},
+		StringValue{"a, b"},
+		ListValue{[]Value{StringValue{"a"}, StringValue{"b"}}},
+		ListValue{[]Value{StringValue{"a, b"}}},
+		ListValue{[]Value{ListValue{[]Value{StringValue{"a"}}}, StringValue{"b"}}},
+		ListValue{},
+		m,
+		StringValue{"a: b"},
+	}
+	def := DefinitionValue{}
+	keys := map[memoKey]Value{}
+	for _, val := range vals {
+		key, ok := newMemoKey(def, val, ListValue{})
+		if !ok {
+			t.Errorf("newMemoKey(%#v) failed", val)
+			continue
+		}
+		if other, ok := keys[key]; ok {
+			t.Errorf("%#v and %#v have the same key", val, other)
+		}
+		keys[key] = val
+	}
+	if _, ok := newMemoKey(def, ListValue{[]Value{StringValue{"a"}, DefinitionValue{}}}, ListValue{}); ok {
+		t.Errorf("newMemoKey succeeded for a list holding a definition")
+	}
+}
+
+func TestMemo(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"memo fib(n) => n if n < 2 else (fib(n - 1)) + fib(n - 2)\nfib(90)", "2880067194370816120"},
+		{"memo f => isnull []\n(f null) << (f \"\")", "truefalse"},
+		{"memo f => count []\n(f l) << \" \" << (f s) where l = (\"a\", \"b\"), s = \"a, b\"", "2 1"},
+		{"id => []\nmemo f => sort(#id) []\n(f b) << \" | \" << b << \" | \" << (f b) where b = (2, 1)", "1, 2 | 2, 1 | 1, 2"},
+	}
+	for _, test := range tests {
+		val, err := NewEngine().Eval(test.code, "")
+		if err != nil || val.String() != test.want {
+			t.Errorf("%q = %v, %v, want %s", test.code, val, err, test.want)
+		}
+	}
+}
+
+func TestMemoLimit(t *testing.T) {
+	e := NewEngine()
+	code := "outer(n) {\n memo inner(x) => x * 2\n inner(n)\n}\n" +
+		"count (outer(i) for i in 0..70000)"
+	if _, err := e.Eval(code, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.env.memos) > maxMemos {
+		t.Errorf("%d results are remembered, want at most %d", len(e.env.memos), maxMemos)
+	}
+}
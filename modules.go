@@ -0,0 +1,124 @@
+package trex
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleValue is the namespace of an imported file. The definitions made at the top
+// level of the file are accessed through it with the '.' operator.
+type ModuleValue struct {
+	name  string
+	scope *scope
+}
+
+func (this ModuleValue) String() string {
+	return "<#Module " + this.name + ">"
+}
+
+// modules caches the files which were imported into an environment, so that every
+// file is only run once no matter how many times it is imported.
+type modules struct {
+	loaded map[string]ModuleValue
+	// loading holds the files which are currently being imported, in the order in
+	// which they were imported, and is used to detect import cycles.
+	loading []string
+}
+
+func (this Import) interpret(env *Environment, input Value) Value {
+	path := this.path
+	if src := this.pos.src; src != nil && src.Name != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(src.Name), path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		panic(newErr(E_IMPORT, "could not import \""+this.path+"\": "+err.Error(), this.pos))
+	}
+	name := this.alias.id
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	env.scope.values[name] = env.importFile(path, this.pos)
+	return NullValue{}
+}
+
+// importFile runs the definitions and imports of the file at path in a scope of its own,
+// unless the file was already imported, and returns the module holding them.
+func (env *Environment) importFile(path string, pos Position) ModuleValue {
+	if mod, ok := env.modules.loaded[path]; ok {
+		return mod
+	}
+	for i, p := range env.modules.loading {
+		if p == path {
+			cycle := append(append([]string{}, env.modules.loading[i:]...), path)
+			for j := range cycle {
+				cycle[j] = filepath.Base(cycle[j])
+			}
+			panic(newErr(E_IMPORT, "import cycle: "+strings.Join(cycle, " -> "), pos))
+		}
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(newErr(E_IMPORT, "could not open file \""+path+"\"", pos))
+	}
+
+	tokens := TokenQueue{}
+	lexProgram(&Source{path, string(content)}, &tokens)
+	if len(tokens.errors) > 0 {
+		panic(errorList(tokens.errors))
+	}
+	prog := parseProgram(&tokens, TT_EOF)
+	if len(tokens.errors) > 0 {
+		panic(errorList(tokens.errors))
+	}
+
+	loading, caller := env.modules.loading, env.scope
+	defer func() {
+		env.modules.loading = loading
+		env.scope = caller
+	}()
+	env.modules.loading = append(loading, path)
+	env.scope = newScope(env.builtins)
+	for _, n := range prog.lines {
+		switch n.(type) {
+		case Definition, Import:
+			n.interpret(env, NullValue{})
+		}
+	}
+	mod := ModuleValue{strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), env.scope}
+	env.modules.loaded[path] = mod
+	return mod
+}
+
+func (this MemberAccess) interpret(env *Environment, input Value) Value {
+	switch v := this.expression.interpret(env, input).(type) {
+	case ModuleValue:
+		if val, ok := v.scope.values[this.member.id]; ok {
+			return val
+		}
+		if def, ok := v.scope.definitions[this.member.id]; ok {
+			return DefinitionValue{def, v.scope}
+		}
+		names := []string{}
+		for k := range v.scope.definitions {
+			names = append(names, k)
+		}
+		err := newErr(E_UNDEFINED, "module "+v.name+" has no definition \""+this.member.id+"\"", this.member.pos)
+		if name, ok := closestName(this.member.id, names); ok {
+			err = err.withHint("did you mean \"" + name + "\"?")
+		}
+		panic(err)
+	case MapValue:
+		if val, ok := v.vals[this.member.id]; ok {
+			return val
+		}
+		err := newErr(E_KEY_NOT_FOUND, "key \""+this.member.id+"\" not found in map", this.member.pos)
+		if name, ok := closestName(this.member.id, v.keys); ok {
+			err = err.withHint("did you mean \"" + name + "\"?")
+		}
+		panic(err)
+	default:
+		panic(newErr(E_WRONG_TYPE, "only modules and maps have members", this.pos))
+	}
+}
@@ -0,0 +1,227 @@
+package trex
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+type numberKind int
+
+const (
+	NUM_INT = iota
+	NUM_BIG
+	NUM_FLOAT
+)
+
+// number is a value which is used in arithmetic. Whole numbers are stored as an int64
+// while they fit in one, and as a big.Int once they don't.
+type number struct {
+	kind numberKind
+	i    int64
+	b    *big.Int
+	f    float64
+}
+
+func intNumber(i int64) number {
+	return number{NUM_INT, i, nil, 0}
+}
+
+func floatNumber(f float64) number {
+	return number{NUM_FLOAT, 0, nil, f}
+}
+
+// bigNumber returns b as a number, shrinking it back to an int64 if it fits in one.
+func bigNumber(b *big.Int) number {
+	if b.IsInt64() {
+		return intNumber(b.Int64())
+	}
+	return number{NUM_BIG, 0, b, 0}
+}
+
+func parseNumber(str string, pos Position) number {
+	if i, err := strconv.ParseInt(str, 0, 64); err == nil {
+		return intNumber(i)
+	} else if err.(*strconv.NumError).Err == strconv.ErrRange {
+		if b, ok := new(big.Int).SetString(str, 0); ok {
+			return bigNumber(b)
+		}
+	}
+	if f, err := strconv.ParseFloat(str, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return floatNumber(f)
+	}
+	panic(conversionError(str, "a number", pos))
+}
+
+func conversionError(str string, to string, pos Position) myErr {
+	if len(str) > 30 {
+		return newErr(E_CONVERSION, strconv.QuoteToGraphic(str[:30])+`... cannot be converted to `+to, pos).withNote("the full value was not shown due to its length")
+	}
+	return newErr(E_CONVERSION, strconv.QuoteToGraphic(str)+` cannot be converted to `+to, pos)
+}
+
+func (n number) toBig() *big.Int {
+	if n.kind == NUM_BIG {
+		return n.b
+	}
+	return big.NewInt(n.i)
+}
+
+func (n number) toFloat() float64 {
+	switch n.kind {
+	case NUM_FLOAT:
+		return n.f
+	case NUM_BIG:
+		f, _ := new(big.Float).SetInt(n.b).Float64()
+		return f
+	default:
+		return float64(n.i)
+	}
+}
+
+func (n number) String() string {
+	switch n.kind {
+	case NUM_FLOAT:
+		if abs := math.Abs(n.f); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+			return strconv.FormatFloat(n.f, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(n.f, 'g', -1, 64)
+	case NUM_BIG:
+		return n.b.String()
+	default:
+		return strconv.FormatInt(n.i, 10)
+	}
+}
+
+func (n number) isZero() bool {
+	switch n.kind {
+	case NUM_FLOAT:
+		return n.f == 0
+	case NUM_BIG:
+		return n.b.Sign() == 0
+	default:
+		return n.i == 0
+	}
+}
+
+func (n number) negate() number {
+	switch n.kind {
+	case NUM_FLOAT:
+		return floatNumber(-n.f)
+	case NUM_BIG:
+		return bigNumber(new(big.Int).Neg(n.b))
+	default:
+		if n.i == math.MinInt64 {
+			return bigNumber(new(big.Int).Neg(n.toBig()))
+		}
+		return intNumber(-n.i)
+	}
+}
+
+// calculate applies one of the arithmetic operators to l and r. Operations on whole
+// numbers stay whole (so division rounds towards zero), unless one of the operands
+// has a fraction. pos is the position of the right operand.
+func calculate(op TokenType, l, r number, pos Position) number {
+	if (op == TT_DIV || op == TT_MOD) && r.isZero() {
+		panic(newErr(E_DIVISION_BY_ZERO, "division by zero", pos))
+	}
+	if l.kind == NUM_FLOAT || r.kind == NUM_FLOAT {
+		a, b := l.toFloat(), r.toFloat()
+		var f float64
+		switch op {
+		case TT_ADD:
+			f = a + b
+		case TT_SUB:
+			f = a - b
+		case TT_MUL:
+			f = a * b
+		case TT_DIV:
+			f = a / b
+		case TT_MOD:
+			f = math.Mod(a, b)
+		}
+		// whole numbers which are too large for a float64 become infinite as well
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			panic(newErr(E_NUMBER_OVERFLOW, "the result is too large to be a number with a fraction", pos).withHint("use whole numbers, which may be of any size"))
+		}
+		return floatNumber(f)
+	}
+	if l.kind == NUM_INT && r.kind == NUM_INT {
+		a, b := l.i, r.i
+		switch op {
+		case TT_ADD:
+			if sum := a + b; (sum > a) == (b > 0) {
+				return intNumber(sum)
+			}
+		case TT_SUB:
+			if diff := a - b; (diff < a) == (b > 0) {
+				return intNumber(diff)
+			}
+		case TT_MUL:
+			if a == 0 || b == 0 {
+				return intNumber(0)
+			}
+			if prod := a * b; prod/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+				return intNumber(prod)
+			}
+		case TT_DIV:
+			if !(a == math.MinInt64 && b == -1) {
+				return intNumber(a / b)
+			}
+		case TT_MOD:
+			if b == -1 {
+				return intNumber(0)
+			}
+			return intNumber(a % b)
+		}
+	}
+	a, b := l.toBig(), r.toBig()
+	switch op {
+	case TT_ADD:
+		return bigNumber(new(big.Int).Add(a, b))
+	case TT_SUB:
+		return bigNumber(new(big.Int).Sub(a, b))
+	case TT_MUL:
+		return bigNumber(new(big.Int).Mul(a, b))
+	case TT_DIV:
+		return bigNumber(new(big.Int).Quo(a, b))
+	default:
+		return bigNumber(new(big.Int).Rem(a, b))
+	}
+}
+
+// rangeBound converts one of the bounds of a range to an integer.
+func rangeBound(str string, pos Position) int64 {
+	n := parseNumber(str, pos)
+	if n.kind != NUM_INT {
+		panic(newErr(E_CONVERSION, "range bounds must be whole numbers which fit in 64 bits", pos))
+	}
+	return n.i
+}
+
+// compareNumbers returns -1 if l < r, 0 if l = r, and +1 if l > r.
+func compareNumbers(l, r number) int {
+	switch {
+	case l.kind == NUM_INT && r.kind == NUM_INT:
+		switch {
+		case l.i < r.i:
+			return -1
+		case l.i > r.i:
+			return 1
+		default:
+			return 0
+		}
+	case l.kind == NUM_FLOAT || r.kind == NUM_FLOAT:
+		a, b := l.toFloat(), r.toFloat()
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	default:
+		return l.toBig().Cmp(r.toBig())
+	}
+}
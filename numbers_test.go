@@ -0,0 +1,90 @@
+package trex
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCalculate(t *testing.T) {
+	maxInt := strconv.FormatInt(math.MaxInt64, 10)
+	minInt := strconv.FormatInt(math.MinInt64, 10)
+	tests := []struct {
+		l    string
+		op   TokenType
+		r    string
+		want string
+	}{
+		{"7", TT_DIV, "2", "3"},
+		{"-7", TT_DIV, "2", "-3"},
+		{"-7", TT_MOD, "2", "-1"},
+		{"7.5", TT_DIV, "2", "3.75"},
+		{"7", TT_MOD, "2.5", "2"},
+		{maxInt, TT_ADD, "1", "9223372036854775808"},
+		{minInt, TT_SUB, "1", "-9223372036854775809"},
+		{minInt, TT_ADD, "-1", "-9223372036854775809"},
+		{maxInt, TT_MUL, "2", "18446744073709551614"},
+		{minInt, TT_MUL, "-1", "9223372036854775808"},
+		{"-1", TT_MUL, minInt, "9223372036854775808"},
+		{minInt, TT_DIV, "-1", "9223372036854775808"},
+		{minInt, TT_MOD, "-1", "0"},
+		{"9223372036854775808", TT_SUB, "1", maxInt},
+		{"9223372036854775808", TT_DIV, "-2", "-4611686018427387904"},
+		{"-9223372036854775809", TT_MOD, "10", "-9"},
+		{"18446744073709551616", TT_MUL, "0.5", "9223372036854776000"},
+		{"0", TT_MUL, minInt, "0"},
+	}
+	for _, test := range tests {
+		l, r := parseNumber(test.l, Position{}), parseNumber(test.r, Position{})
+		if got := calculate(test.op, l, r, Position{}).String(); got != test.want {
+			t.Errorf("%s %v %s = %s, want %s", test.l, test.op, test.r, got, test.want)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	for _, code := range []string{"1 / 0", "1 % 0", "1.5 / 0", "9223372036854775808 % 0", "1 / 0.0"} {
+		_, err := NewEngine().Eval(code, "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_DIVISION_BY_ZERO {
+			t.Errorf("%q returned %v, want an error with code %v", code, err, E_DIVISION_BY_ZERO)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"0xff", "255"},
+		{"1.5e3 + 0", "1500"},
+		{"1e300 * 1", "1e+300"},
+		{"2E-3 * 1000", "2"},
+		{"1e+2 + 0", "100"},
+		{"1e21 * 1", "1e+21"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"(1, 2, 3)[1]", "2"},
+		{"1..3", "1, 2"},
+	}
+	for _, test := range tests {
+		val, err := NewEngine().Eval(test.code, "")
+		if err != nil || val.String() != test.want {
+			t.Errorf("%q = %v, %v, want %s", test.code, val, err, test.want)
+		}
+	}
+}
+
+func TestFloatOverflow(t *testing.T) {
+	huge := "1" + strings.Repeat("0", 400)
+	for _, code := range []string{"1e308 * 10", "-1e308 - 1e308", "1e308 / 0.1", huge + " * 0.5", huge + " + 1.5"} {
+		_, err := NewEngine().Eval(code, "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_NUMBER_OVERFLOW {
+			t.Errorf("%q returned %v, want an error with code %v", code, err, E_NUMBER_OVERFLOW)
+		}
+	}
+	val, err := NewEngine().Eval(huge+" * 2", "")
+	if err != nil || val.String() != "2"+strings.Repeat("0", 400) {
+		t.Errorf("whole numbers which are too large for a float failed: %v", err)
+	}
+}
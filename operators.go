@@ -1,4 +1,4 @@
-package main
+package trex
 
 type Operator struct {
 	ty            TokenType
@@ -10,7 +10,7 @@ type Operator struct {
 
 func precedence(token Token) byte {
 	switch token.ty {
-	case TT_IDENTIFIER, TT_LITERAL, TT_WHITESPACE:
+	case TT_IDENTIFIER, TT_LITERAL, TT_TEMPLATE, TT_WHITESPACE:
 		return 135
 	default:
 		return getOperator(token.data).precedence
@@ -54,6 +54,8 @@ func getOperator(str string) Operator {
 		return Operator{TT_CURLY_BRACES_CLOSE, str, LEFT_TO_RIGHT, 0, false}
 	case "[":
 		return Operator{TT_SQUARE_BRACKETS_OPEN, str, LEFT_TO_RIGHT, 140, false}
+	case ".":
+		return Operator{TT_DOT, str, LEFT_TO_RIGHT, 140, false}
 	case "]":
 		return Operator{TT_SQUARE_BRACKETS_CLOSE, str, LEFT_TO_RIGHT, 0, false}
 	case "#":
@@ -70,6 +72,8 @@ func getOperator(str string) Operator {
 		return Operator{TT_STRING_MUL, str, LEFT_TO_RIGHT, 100, true}
 	case "..":
 		return Operator{TT_RANGE, str, LEFT_TO_RIGHT, 95, true}
+	case "...":
+		return Operator{TT_ELLIPSIS, str, false, 140, false}
 	case "+":
 		return Operator{TT_ADD, str, LEFT_TO_RIGHT, 90, true}
 	case "-":
@@ -104,14 +108,20 @@ func getOperator(str string) Operator {
 		return Operator{TT_AND, str, LEFT_TO_RIGHT, 60, true}
 	case "or":
 		return Operator{TT_OR, str, LEFT_TO_RIGHT, 50, true}
+	case "|>":
+		return Operator{TT_PIPE, str, LEFT_TO_RIGHT, 45, false}
 	case ",":
 		return Operator{TT_COMMA, str, RIGHT_TO_LEFT, 40, false}
 	case "from":
 		return Operator{TT_FROM, str, LEFT_TO_RIGHT, 35, true}
 	case "for":
 		return Operator{TT_FOR, str, false, 35, false}
+	case "match":
+		return Operator{TT_MATCH, str, false, 35, false}
 	case "if":
 		return Operator{TT_IF, str, RIGHT_TO_LEFT, 30, false}
+	case "where":
+		return Operator{TT_WHERE, str, LEFT_TO_RIGHT, 25, false}
 	case ":":
 		return Operator{TT_COLON, str, LEFT_TO_RIGHT, 20, false}
 	case "->":
@@ -134,17 +144,17 @@ func getOperator(str string) Operator {
 		return Operator{TT_DOUBLE_QUOTE, str, false, 0, false}
 	case "else":
 		return Operator{TT_ELSE, str, false, 0, false}
+	case "import":
+		return Operator{TT_IMPORT, str, false, 0, false}
+	case "as":
+		return Operator{TT_AS, str, false, 0, false}
+	case "memo":
+		return Operator{TT_MEMO, str, false, 0, false}
 	default:
 		return Operator{TT_UNKNOWN, str, false, 0, false}
 	}
 }
 
-func getWordOperators() []string {
-	return []string{
-		"else", "for", "in", "and", "if", "from", "or", "not", "exit", "help", "quit", "example",
-	}
-}
-
 func getOperatorByType(op TokenType) Operator {
 	switch op {
 	case TT_PARENTHESIS_OPEN:
@@ -159,6 +169,8 @@ func getOperatorByType(op TokenType) Operator {
 		return Operator{TT_SQUARE_BRACKETS_OPEN, "[", LEFT_TO_RIGHT, 140, true}
 	case TT_SQUARE_BRACKETS_CLOSE:
 		return Operator{TT_SQUARE_BRACKETS_CLOSE, "]", LEFT_TO_RIGHT, 0, false}
+	case TT_DOT:
+		return Operator{TT_DOT, ".", LEFT_TO_RIGHT, 140, false}
 	case TT_INDIRECTION:
 		return Operator{TT_INDIRECTION, "#", LEFT_TO_RIGHT, 130, false}
 	case TT_NOT:
@@ -173,6 +185,8 @@ func getOperatorByType(op TokenType) Operator {
 		return Operator{TT_STRING_MUL, "**", LEFT_TO_RIGHT, 100, true}
 	case TT_RANGE:
 		return Operator{TT_RANGE, "..", LEFT_TO_RIGHT, 95, true}
+	case TT_ELLIPSIS:
+		return Operator{TT_ELLIPSIS, "...", false, 140, false}
 	case TT_ADD:
 		return Operator{TT_ADD, "+", LEFT_TO_RIGHT, 90, true}
 	case TT_SUB:
@@ -207,14 +221,20 @@ func getOperatorByType(op TokenType) Operator {
 		return Operator{TT_AND, "and", LEFT_TO_RIGHT, 60, true}
 	case TT_OR:
 		return Operator{TT_OR, "or", LEFT_TO_RIGHT, 50, true}
+	case TT_PIPE:
+		return Operator{TT_PIPE, "|>", LEFT_TO_RIGHT, 45, false}
 	case TT_COMMA:
 		return Operator{TT_COMMA, ",", RIGHT_TO_LEFT, 40, false}
 	case TT_FROM:
 		return Operator{TT_FROM, "from", LEFT_TO_RIGHT, 35, true}
 	case TT_FOR:
 		return Operator{TT_FOR, "for", false, 35, false}
+	case TT_MATCH:
+		return Operator{TT_MATCH, "match", false, 35, false}
 	case TT_IF:
 		return Operator{TT_IF, "if", RIGHT_TO_LEFT, 30, false}
+	case TT_WHERE:
+		return Operator{TT_WHERE, "where", LEFT_TO_RIGHT, 25, false}
 	case TT_COLON:
 		return Operator{TT_COLON, ":", LEFT_TO_RIGHT, 20, false}
 	case TT_ANON_DEFINE:
@@ -237,6 +257,12 @@ func getOperatorByType(op TokenType) Operator {
 		return Operator{TT_DOUBLE_QUOTE, "\"", false, 0, false}
 	case TT_ELSE:
 		return Operator{TT_ELSE, "else", false, 0, false}
+	case TT_IMPORT:
+		return Operator{TT_IMPORT, "import", false, 0, false}
+	case TT_AS:
+		return Operator{TT_AS, "as", false, 0, false}
+	case TT_MEMO:
+		return Operator{TT_MEMO, "memo", false, 0, false}
 	default:
 		return Operator{TT_UNKNOWN, "", false, 0, false}
 	}
@@ -308,4 +334,13 @@ const (
 	TT_INDIRECTION
 	TT_DEFINE
 	TT_ANON_DEFINE
+	TT_DOT
+	TT_IMPORT
+	TT_AS
+	TT_TEMPLATE
+	TT_ELLIPSIS
+	TT_MATCH
+	TT_WHERE
+	TT_PIPE
+	TT_MEMO
 )
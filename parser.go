@@ -1,36 +1,73 @@
-package main
+package trex
 
+import "regexp"
+
+// parseProgram parses lines until the expected token. Syntax errors are recorded in
+// tokens rather than panicking, so that all of the errors in the code are found.
 func parseProgram(tokens *TokenQueue, expected TokenType) Program {
 	prog := Program{nil, tokens.peek().pos}
 	eatWS(tokens)
 	eatToken(tokens, TT_TERMINATOR)
-	defer func() {
-		if err := recover(); err != nil {
-			if globals.codeFile == "" {
-				for !eatToken(tokens, TT_TERMINATOR) && !eatToken(tokens, expected) {
-					tokens.next() // forward to the next line since this one is invalid
-				}
-			}
-			switch e := err.(type) {
-			case error:
-				printError(e)
-			default:
-				panic(err)
-			}
-		}
-	}()
 	for !eatToken(tokens, expected) {
-		node := parse(tokens, 0)
-		if node != nil {
+		if tokens.peek().ty == TT_EOF {
+			tokens.errors = append(tokens.errors, newErr(E_EXPECTED_TOKEN, "\""+getOperatorByType(expected).str+"\" expected", tokens.peek().pos).withLabel(prog.pos, "in the block which starts here"))
+			return prog
+		}
+		if node := parseLine(tokens, expected); node != nil {
 			prog.lines = append(prog.lines, node)
 		}
-		eatWS(tokens)
-		if eatToken(tokens, expected) {
-			return prog
+	}
+	return prog
+}
+
+// parseLine parses a single line of a program along with the terminator after it.
+// If the line has a syntax error, the error is recorded and the rest of the line
+// is skipped.
+func parseLine(tokens *TokenQueue, expected TokenType) (node Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(myErr)
+			if !ok {
+				panic(r)
+			}
+			tokens.errors = append(tokens.errors, err)
+			skipLine(tokens, expected)
+			node = nil
 		}
+	}()
+	node = parse(tokens, 0)
+	eatWS(tokens)
+	if tokens.peek().ty != expected {
 		expectToken(tokens, TT_TERMINATOR)
 	}
-	return prog
+	return node
+}
+
+// skipLine skips tokens until the end of the current line, which is either a
+// terminator (which is skipped as well) or the token which ends the program.
+func skipLine(tokens *TokenQueue, expected TokenType) {
+	depth := 0
+	for {
+		switch tokens.peek().ty {
+		case TT_EOF:
+			return
+		case TT_PARENTHESIS_OPEN, TT_SQUARE_BRACKETS_OPEN, TT_CURLY_BRACES_OPEN:
+			depth++
+		case TT_PARENTHESIS_CLOSE, TT_SQUARE_BRACKETS_CLOSE, TT_CURLY_BRACES_CLOSE:
+			if depth == 0 && tokens.peek().ty == expected {
+				return
+			}
+			if depth > 0 {
+				depth--
+			}
+		case TT_TERMINATOR:
+			if depth == 0 {
+				tokens.next()
+				return
+			}
+		}
+		tokens.next()
+	}
 }
 
 func eatWS(tokens *TokenQueue) bool {
@@ -62,7 +99,14 @@ func parse(tokens *TokenQueue, lastPrecedence byte) Node {
 
 func expectToken(tokens *TokenQueue, ty TokenType) {
 	if !eatToken(tokens, ty) {
-		panic(myErr{"\"" + getOperatorByType(ty).str + "\" expected", tokens.peek().pos, ERR_PARSER})
+		panic(newErr(E_EXPECTED_TOKEN, "\""+getOperatorByType(ty).str+"\" expected", tokens.peek().pos))
+	}
+}
+
+// expectClosing is like expectToken, for a token which closes the token at open.
+func expectClosing(tokens *TokenQueue, ty TokenType, open Position) {
+	if !eatToken(tokens, ty) {
+		panic(newErr(E_EXPECTED_TOKEN, "\""+getOperatorByType(ty).str+"\" expected", tokens.peek().pos).withLabel(open, "to close this"))
 	}
 }
 
@@ -82,11 +126,11 @@ func parseForClause(tokens *TokenQueue) []ForClause {
 		switch bo := i.(type) {
 		case BinaryOperation:
 			if bo.op.ty != TT_IN {
-				panic(myErr{"expected a for clause", i.getPosition(), ERR_PARSER})
+				panic(newErr(E_EXPECTED_FOR_CLAUSE, "expected a for clause", i.getPosition()))
 			}
 			ret = append(ret, ForClause{convertToIdentifier(bo.left), bo.right})
 		default:
-			panic(myErr{"expected a for clause" + bo.toString(), i.getPosition(), ERR_PARSER})
+			panic(newErr(E_EXPECTED_FOR_CLAUSE, "expected a for clause", i.getPosition()))
 		}
 	}
 	return ret
@@ -105,13 +149,25 @@ func parseExpression(tokens *TokenQueue, prec byte) Expression {
 	if exp := parseOptionalExpression(tokens, prec); exp != nil {
 		return exp
 	}
-	panic(myErr{"Expected an expression.", pos, ERR_PARSER})
+	panic(newErr(E_EXPECTED_EXPRESSION, "Expected an expression.", pos))
 }
 
 func parseIdentifier(tokens *TokenQueue, prec byte) Identifier {
 	return convertToIdentifier(parse(tokens, prec))
 }
 
+// parseReference parses the operand of the '#' operator, which is either an
+// identifier or a member of a module.
+func parseReference(tokens *TokenQueue, prec byte) Expression {
+	node := parse(tokens, prec)
+	if call, ok := node.(FunctionCall); ok {
+		if member, ok := convertToCallee(call).(MemberAccess); ok {
+			return member
+		}
+	}
+	return convertToIdentifier(node)
+}
+
 func parseIdentifierList(tokens *TokenQueue) IdentifierList {
 	return convertToIdentifierList(parse(tokens, 0))
 }
@@ -123,7 +179,7 @@ func convertToCallee(node Node) Expression {
 	switch v := node.(type) {
 	case FunctionCall:
 		if v.arg == nil && len(v.params.expressions) == 0 {
-			return convertToIdentifier(v)
+			return v.callee
 		}
 		return v
 	default:
@@ -138,10 +194,12 @@ func convertToExpression(node Node) Expression {
 	switch v := node.(type) {
 	case Identifier:
 		return FunctionCall{v, ExpressionList{}, nil, v.pos}
+	case MemberAccess:
+		return FunctionCall{v, ExpressionList{}, nil, v.pos}
 	case Expression:
 		return v
 	default:
-		panic(myErr{"expected an expression", v.getPosition(), ERR_PARSER})
+		panic(newErr(E_EXPECTED_EXPRESSION, "expected an expression", v.getPosition()))
 	}
 }
 
@@ -154,32 +212,66 @@ func convertToIdentifier(node Node) Identifier {
 		return v
 	case FunctionCall:
 		if v.arg != nil || len(v.params.expressions) != 0 {
-			panic(myErr{"expected an identifier", v.getPosition(), ERR_PARSER})
+			panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier", v.getPosition()))
 		}
 		return convertToIdentifier(v.callee)
 	default:
-		panic(myErr{"expected an identifier", v.getPosition(), ERR_PARSER})
+		panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier", v.getPosition()))
 	}
 }
 
+// convertToIdentifierList converts the parameters of a definition to an identifier
+// list. A parameter may be given a default value, as in "fill = ' '", and the last
+// parameter may be followed by "..." to collect the parameters after the others.
 func convertToIdentifierList(node Node) IdentifierList {
 	if node == nil {
 		return IdentifierList{}
 	}
 	switch v := node.(type) {
 	case ExpressionList:
-		ret := IdentifierList{[]Identifier{}, v.pos}
+		ret := IdentifierList{[]Identifier{}, nil, false, v.pos}
 		for _, exp := range v.expressions {
-			ret.identifiers = append(ret.identifiers, convertToIdentifier(exp))
+			ret.addParameter(exp)
 		}
 		return ret
 	case IdentifierList:
 		return v
 	case Expression:
-		return IdentifierList{[]Identifier{convertToIdentifier(v)}, v.getPosition()}
+		ret := IdentifierList{[]Identifier{}, nil, false, v.getPosition()}
+		ret.addParameter(v)
+		return ret
 	default:
-		panic(myErr{"expected an identifier list", v.getPosition(), ERR_PARSER})
+		panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier list", v.getPosition()))
+	}
+}
+
+// addParameter adds the parameter exp to the end of the list.
+func (list *IdentifierList) addParameter(exp Expression) {
+	if list.variadic {
+		panic(newErr(E_INVALID_PARAMS, "no parameters can come after a parameter with \"...\"", exp.getPosition()))
+	}
+	switch e := exp.(type) {
+	case BinaryOperation:
+		if e.op.ty == TT_EQUAL {
+			if list.defaults == nil {
+				list.defaults = make([]Expression, len(list.identifiers))
+			}
+			list.identifiers = append(list.identifiers, convertToIdentifier(e.left))
+			list.defaults = append(list.defaults, e.right)
+			return
+		}
+	case VariadicParameter:
+		list.identifiers = append(list.identifiers, e.id)
+		list.variadic = true
+		if list.defaults != nil {
+			list.defaults = append(list.defaults, nil)
+		}
+		return
 	}
+	if list.defaults != nil {
+		panic(newErr(E_INVALID_PARAMS, "parameters without a default value must come before those with one", exp.getPosition()))
+	}
+	list.identifiers = append(list.identifiers, convertToIdentifier(exp))
 }
 
 func convertToExpressionList(node Node) ExpressionList {
@@ -192,7 +284,7 @@ func convertToExpressionList(node Node) ExpressionList {
 	case Expression:
 		return ExpressionList{[]Expression{v}, v.getPosition()}
 	default:
-		panic(myErr{"expected an expression list", v.getPosition(), ERR_PARSER})
+		panic(newErr(E_EXPECTED_EXPRESSION, "expected an expression list", v.getPosition()))
 	}
 }
 
@@ -206,14 +298,29 @@ func nud(tokens *TokenQueue) Expression {
 	case TT_LITERAL:
 		tokens.next()
 		return Literal{token.data, token.pos}
+	case TT_TEMPLATE:
+		tokens.next()
+		return parseTemplate(tokens, token)
 	case TT_PARENTHESIS_OPEN:
 		tokens.next()
 		inner := parseOptionalExpression(tokens, 0)
-		expectToken(tokens, TT_PARENTHESIS_CLOSE)
+		expectClosing(tokens, TT_PARENTHESIS_CLOSE, token.pos)
 		if inner == nil {
 			return EmptyExpression{token.pos}
 		}
 		return inner
+	case TT_CURLY_BRACES_OPEN:
+		tokens.next()
+		return parseMapLiteral(tokens, token.pos)
+	case TT_IMPORT:
+		tokens.next()
+		return parseImport(tokens, token.pos)
+	case TT_MATCH:
+		tokens.next()
+		return parseMatch(tokens, nil, token.pos)
+	case TT_MEMO:
+		tokens.next()
+		return parseMemo(tokens, token.pos)
 	case TT_ANON_DEFINE:
 		tokens.next()
 		return AnonDefinition{IdentifierList{}, parseExpression(tokens, leftPrecedenceByTy(TT_ANON_DEFINE)), token.pos}
@@ -233,7 +340,7 @@ func nud(tokens *TokenQueue) Expression {
 		} else if eatToken(tokens, TT_SQUARE_BRACKETS_CLOSE) {
 			return node
 		} else {
-			panic(myErr{"expected ']' or ':'", tokens.peek().pos, ERR_PARSER})
+			panic(newErr(E_EXPECTED_TOKEN, "expected ']' or ':'", tokens.peek().pos).withLabel(token.pos, "to close this"))
 		}
 		eatWS(tokens)
 		if eatToken(tokens, TT_COLON) {
@@ -244,23 +351,328 @@ func nud(tokens *TokenQueue) Expression {
 		} else if eatToken(tokens, TT_SQUARE_BRACKETS_CLOSE) {
 			return node
 		} else {
-			panic(myErr{"expected ']' or ':'", tokens.peek().pos, ERR_PARSER})
+			panic(newErr(E_EXPECTED_TOKEN, "expected ']' or ':'", tokens.peek().pos).withLabel(token.pos, "to close this"))
 		}
 		eatWS(tokens)
-		expectToken(tokens, TT_SQUARE_BRACKETS_CLOSE)
+		expectClosing(tokens, TT_SQUARE_BRACKETS_CLOSE, token.pos)
 		return node
 	}
 	if isUnaryOperator(token.ty) {
 		tokens.next()
 		op := getOperator(token.data)
 		if op.ty == TT_INDIRECTION {
-			return UnaryOperation{parseIdentifier(tokens, leftPrecedenceByTy(TT_INDIRECTION)), op, token.pos}
+			return UnaryOperation{parseReference(tokens, leftPrecedenceByTy(TT_INDIRECTION)), op, token.pos}
 		}
 		return UnaryOperation{parseExpression(tokens, leftPrecedence(token)), op, token.pos}
 	}
 	return nil
 }
 
+// parseTemplate splits the text of a template literal into literals and the expressions
+// embedded in it with "${...}". A "$" can be written as "\$" to not start an expression.
+func parseTemplate(tokens *TokenQueue, tok Token) Template {
+	node := Template{nil, tok.pos}
+	runes := []rune(tok.data)
+	// the position of runes[i], which starts right after the opening backtick
+	line, col := tok.pos.line, tok.pos.start+1
+	advance := func(r rune) {
+		if r == '\n' {
+			line++
+			col = 0
+		} else {
+			col++
+		}
+	}
+	text, textPos := "", Position{line, col, col, tok.pos.src}
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '$' {
+			text += "$"
+			advance(runes[i])
+			advance(runes[i+1])
+			i++
+			continue
+		}
+		if runes[i] != '$' || i+1 >= len(runes) || runes[i+1] != '{' {
+			text += string(runes[i])
+			advance(runes[i])
+			continue
+		}
+		if text != "" {
+			node.parts = append(node.parts, Literal{text, textPos})
+		}
+		open := Position{line, col, col + 2, tok.pos.src}
+		end := closingBrace(runes, i+2)
+		if end == -1 {
+			panic(newErr(E_EXPECTED_TOKEN, "\"}\" expected", tok.pos).withLabel(open, "to close this"))
+		}
+		advance(runes[i])
+		advance(runes[i+1])
+		expLine, expCol := line, col
+		for _, r := range runes[i+2 : end] {
+			advance(r)
+		}
+		node.parts = append(node.parts, parseEmbedded(tokens, string(runes[i+2:end]), expLine, expCol, Position{line, col, col + 1, tok.pos.src}))
+		advance(runes[end])
+		i = end
+		text, textPos = "", Position{line, col, col, tok.pos.src}
+	}
+	if text != "" || len(node.parts) == 0 {
+		node.parts = append(node.parts, Literal{text, textPos})
+	}
+	return node
+}
+
+// closingBrace returns the index of the '}' which closes an embedded expression starting
+// at runes[start], skipping over braces inside of nested braces and quotes. It returns -1
+// if there isn't one.
+func closingBrace(runes []rune, start int) int {
+	depth := 0
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\'', '"':
+			quote := runes[i]
+			for i++; i < len(runes) && runes[i] != quote; i++ {
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// parseEmbedded parses an expression embedded in a template literal. The expression's
+// code starts at the given line and column, and close is the position of the '}' after it.
+func parseEmbedded(tokens *TokenQueue, code string, line int, col int, close Position) Expression {
+	inner := TokenQueue{}
+	lexAt(close.src, code, line, col, &inner)
+	inner.pushBack(Token{TT_EOF, "", close})
+	if len(inner.errors) > 0 {
+		tokens.errors = append(tokens.errors, inner.errors...)
+		return Literal{"", close}
+	}
+	exp := parseExpression(&inner, 0)
+	eatWS(&inner)
+	if !eatToken(&inner, TT_EOF) {
+		panic(newErr(E_EXPECTED_TOKEN, "\"}\" expected", inner.peek().pos))
+	}
+	return exp
+}
+
+// pipeInto returns the call of right with left as its argument, so that "a |> f(b)"
+// is the same as "f(b) a". If right already has an argument, it is called and the
+// definition it returns is called with left.
+func pipeInto(left Expression, right Expression) FunctionCall {
+	if call, ok := right.(FunctionCall); ok && call.arg == nil {
+		call.arg = left
+		return call
+	}
+	return FunctionCall{right, ExpressionList{}, left, right.getPosition()}
+}
+
+// parseWhere parses the bindings of a where expression, after the "where" keyword.
+func parseWhere(tokens *TokenQueue, expression Expression) Where {
+	node := Where{expression, nil, expression.getPosition()}
+	prec := getOperatorByType(TT_COMMA).precedence
+	for {
+		eatWS(tokens)
+		tok := tokens.next()
+		if tok.ty != TT_IDENTIFIER {
+			panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier", tok.pos))
+		}
+		eatWS(tokens)
+		expectToken(tokens, TT_EQUAL)
+		value := parseExpression(tokens, prec)
+		node.bindings = append(node.bindings, Binding{Identifier{tok.data, tok.pos}, value})
+		node.pos.end = value.getPosition().end
+		ateWS := eatWS(tokens)
+		if eatToken(tokens, TT_COMMA) {
+			continue
+		}
+		if ateWS {
+			tokens.pushFront(Token{TT_WHITESPACE, " ", tokens.peek().pos})
+		}
+		return node
+	}
+}
+
+// parseMatch parses the arms of a match expression, after the "match" keyword.
+// subject is nil if the match is on the argument.
+func parseMatch(tokens *TokenQueue, subject Expression, pos Position) Match {
+	node := Match{subject, nil, pos}
+	eatWS(tokens)
+	open := tokens.peek().pos
+	expectToken(tokens, TT_CURLY_BRACES_OPEN)
+	for {
+		for eatWS(tokens) || eatToken(tokens, TT_TERMINATOR) {
+		}
+		if eatToken(tokens, TT_CURLY_BRACES_CLOSE) {
+			return node
+		}
+		if tokens.peek().ty == TT_EOF {
+			panic(newErr(E_EXPECTED_TOKEN, "\"}\" expected", tokens.peek().pos).withLabel(open, "to close this"))
+		}
+		arm := MatchArm{parsePattern(tokens), nil, nil}
+		eatWS(tokens)
+		if eatToken(tokens, TT_IF) {
+			arm.guard = parseExpression(tokens, leftPrecedenceByTy(TT_ANON_DEFINE))
+			eatWS(tokens)
+		}
+		expectToken(tokens, TT_ANON_DEFINE)
+		arm.result = parseExpression(tokens, 0)
+		node.arms = append(node.arms, arm)
+	}
+}
+
+// parsePattern parses the pattern of a match arm, which is one of:
+//
+//	_              matches anything
+//	name           matches anything, and binds it to name
+//	'abc' or 12    matches a value which is equal to the literal
+//	re'(?P<n>\d+)' matches a value which the regular expression matches, and binds its named groups
+//	(p1, p2)       matches a list of two values, which match p1 and p2
+//	(p1, rest...)  matches a list of at least one value, binding the values after the first one to rest
+func parsePattern(tokens *TokenQueue) Pattern {
+	eatWS(tokens)
+	tok := tokens.next()
+	switch tok.ty {
+	case TT_IDENTIFIER:
+		if tok.data == "_" {
+			return Pattern{PAT_WILDCARD, "", nil, nil, "", tok.pos}
+		}
+		if tok.data == "re" && tokens.peek().ty == TT_LITERAL {
+			lit := tokens.next()
+			re, err := regexp.Compile(lit.data)
+			if err != nil {
+				panic(newErr(E_INVALID_REGEX, "invalid regular expression: "+err.Error(), lit.pos))
+			}
+			return Pattern{PAT_REGEX, lit.data, re, nil, "", tok.pos}
+		}
+		return Pattern{PAT_BIND, tok.data, nil, nil, "", tok.pos}
+	case TT_LITERAL:
+		return Pattern{PAT_LITERAL, tok.data, nil, nil, "", tok.pos}
+	case TT_SUB:
+		if lit := tokens.peek(); lit.ty == TT_LITERAL {
+			tokens.next()
+			return Pattern{PAT_LITERAL, parseNumber("-"+lit.data, lit.pos).String(), nil, nil, "", tok.pos}
+		}
+	case TT_PARENTHESIS_OPEN:
+		node := Pattern{PAT_LIST, "", nil, []Pattern{}, "", tok.pos}
+		eatWS(tokens)
+		if eatToken(tokens, TT_PARENTHESIS_CLOSE) {
+			return node
+		}
+		for {
+			elem := parsePattern(tokens)
+			if eatToken(tokens, TT_ELLIPSIS) {
+				if elem.kind != PAT_BIND && elem.kind != PAT_WILDCARD {
+					panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier before \"...\"", elem.pos))
+				}
+				node.rest = elem.toString()
+				eatWS(tokens)
+				expectClosing(tokens, TT_PARENTHESIS_CLOSE, tok.pos)
+				return node
+			}
+			node.elems = append(node.elems, elem)
+			eatWS(tokens)
+			if eatToken(tokens, TT_PARENTHESIS_CLOSE) {
+				return node
+			}
+			if !eatToken(tokens, TT_COMMA) {
+				panic(newErr(E_EXPECTED_TOKEN, "expected ',' or ')'", tokens.peek().pos).withLabel(tok.pos, "to close this"))
+			}
+		}
+	}
+	panic(newErr(E_EXPECTED_PATTERN, "expected a pattern", tok.pos))
+}
+
+// parseMemo parses the definition after the "memo" keyword, and marks it to have its
+// results cached.
+func parseMemo(tokens *TokenQueue, pos Position) Definition {
+	node := parseExpression(tokens, 0)
+	def, ok := node.(Definition)
+	if !ok {
+		panic(newErr(E_EXPECTED_DEFINITION, "expected a definition", node.getPosition()).withLabel(pos, "only definitions can be memoized"))
+	}
+	def.memo = true
+	return def
+}
+
+// parseImport parses the rest of an import statement, after the "import" keyword.
+// The imported file is either given as a string, or as an identifier which stands
+// for the file with that name and the ".trex" extension.
+func parseImport(tokens *TokenQueue, pos Position) Import {
+	eatWS(tokens)
+	node := Import{"", Identifier{}, pos}
+	switch tok := tokens.next(); tok.ty {
+	case TT_LITERAL:
+		node.path = tok.data
+	case TT_IDENTIFIER:
+		node.path = tok.data + ".trex"
+	default:
+		panic(newErr(E_EXPECTED_TOKEN, "expected the name of a file to import", tok.pos))
+	}
+	node.pos.end = tokens.peek().pos.start
+	eatWS(tokens)
+	if eatToken(tokens, TT_AS) {
+		eatWS(tokens)
+		tok := tokens.next()
+		if tok.ty != TT_IDENTIFIER {
+			panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier", tok.pos))
+		}
+		node.alias = Identifier{tok.data, tok.pos}
+	}
+	return node
+}
+
+// parseMapLiteral parses the entries of a map literal, up to and including its closing brace.
+func parseMapLiteral(tokens *TokenQueue, pos Position) MapLiteral {
+	node := MapLiteral{nil, nil, pos}
+	prec := getOperatorByType(TT_COMMA).precedence
+	eatWhitespaceAndTerminators := func() {
+		for eatWS(tokens) || eatToken(tokens, TT_TERMINATOR) {
+		}
+	}
+	eatWhitespaceAndTerminators()
+	for !eatToken(tokens, TT_CURLY_BRACES_CLOSE) {
+		node.keys = append(node.keys, parseMapKey(tokens, prec))
+		eatWS(tokens)
+		expectToken(tokens, TT_COLON)
+		node.vals = append(node.vals, parseExpression(tokens, prec))
+		eatWhitespaceAndTerminators()
+		if eatToken(tokens, TT_CURLY_BRACES_CLOSE) {
+			break
+		}
+		if !eatToken(tokens, TT_COMMA) {
+			panic(newErr(E_EXPECTED_TOKEN, "expected ',' or '}'", tokens.peek().pos).withLabel(pos, "to close this"))
+		}
+		eatWhitespaceAndTerminators()
+	}
+	return node
+}
+
+// parseMapKey parses the key of a map entry. A key which is a lone identifier stands
+// for its own name, so "{a: 1}" is the same as "{'a': 1}".
+func parseMapKey(tokens *TokenQueue, prec byte) Expression {
+	if tokens.peek().ty != TT_IDENTIFIER {
+		return parseExpression(tokens, prec)
+	}
+	id := tokens.next()
+	ws := tokens.peek()
+	if eatWS(tokens) && tokens.peek().ty != TT_COLON {
+		tokens.pushFront(ws)
+	}
+	if tokens.peek().ty == TT_COLON {
+		return Literal{id.data, id.pos}
+	}
+	tokens.pushFront(id)
+	return parseExpression(tokens, prec)
+}
+
 func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 	token := tokens.peek()
 	left := convertToExpression(node)
@@ -271,7 +683,8 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 		eatWS(tokens)
 		expectToken(tokens, TT_ELSE)
 		elseB := parseExpression(tokens, leftPrecedenceByTy(TT_IF))
-		pos := Position{left.getPosition().line, left.getPosition().start, elseB.getPosition().end}
+		pos := left.getPosition()
+		pos.end = elseB.getPosition().end
 		return Conditional{left, elseB, cond, pos}
 	case TT_ANON_DEFINE:
 		tokens.next()
@@ -307,7 +720,27 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 			IdentifierList{},
 			parseProgram(tokens, TT_CURLY_BRACES_CLOSE),
 			left.getPosition(),
+			false,
+		}
+	case TT_MATCH:
+		tokens.next()
+		return parseMatch(tokens, left, left.getPosition())
+	case TT_ELLIPSIS:
+		tokens.next()
+		return VariadicParameter{convertToIdentifier(left), left.getPosition()}
+	case TT_WHERE:
+		tokens.next()
+		return parseWhere(tokens, left)
+	case TT_PIPE:
+		tokens.next()
+		return pipeInto(left, parseExpression(tokens, leftPrecedenceByTy(TT_PIPE)))
+	case TT_DOT:
+		tokens.next()
+		tok := tokens.next()
+		if tok.ty != TT_IDENTIFIER {
+			panic(newErr(E_EXPECTED_IDENTIFIER, "expected an identifier", tok.pos))
 		}
+		return MemberAccess{left, Identifier{tok.data, tok.pos}, left.getPosition()}
 	case TT_FROM:
 		id := convertToIdentifier(left)
 		pos := tokens.peek().pos
@@ -347,7 +780,7 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 		} else if eatToken(tokens, TT_SQUARE_BRACKETS_CLOSE) {
 			return node
 		} else {
-			panic(myErr{"expected ']' or ':'", tokens.peek().pos, ERR_PARSER})
+			panic(newErr(E_EXPECTED_TOKEN, "expected ']' or ':'", tokens.peek().pos).withLabel(token.pos, "to close this"))
 		}
 		eatWS(tokens)
 		if eatToken(tokens, TT_COLON) {
@@ -358,10 +791,10 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 		} else if eatToken(tokens, TT_SQUARE_BRACKETS_CLOSE) {
 			return node
 		} else {
-			panic(myErr{"expected ']' or ':'", tokens.peek().pos, ERR_PARSER})
+			panic(newErr(E_EXPECTED_TOKEN, "expected ']' or ':'", tokens.peek().pos).withLabel(token.pos, "to close this"))
 		}
 		eatWS(tokens)
-		expectToken(tokens, TT_SQUARE_BRACKETS_CLOSE)
+		expectClosing(tokens, TT_SQUARE_BRACKETS_CLOSE, token.pos)
 		return node
 	}
 	if getOperator(token.data).isBinary {
@@ -373,11 +806,11 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 	if token.ty == TT_PARENTHESIS_OPEN {
 		tokens.next()
 		right = parseOptionalExpression(tokens, 0)
-		expectToken(tokens, TT_PARENTHESIS_CLOSE)
+		expectClosing(tokens, TT_PARENTHESIS_CLOSE, token.pos)
 	} else {
 		right = parseExpression(tokens, functionPrecedence)
 		if !ateWS && right != nil {
-			panic(myErr{"expected whitespace", right.getPosition(), ERR_PARSER})
+			panic(newErr(E_EXPECTED_WHITESPACE, "expected whitespace", right.getPosition()))
 		}
 	}
 
@@ -390,7 +823,7 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 		params := convertToIdentifierList(right)
 		exp := parseExpression(tokens, 0)
 		prog := Program{[]Node{exp}, exp.getPosition()}
-		return Definition{id, params, prog, id.pos}
+		return Definition{id, params, prog, id.pos, false}
 	}
 	if eatToken(tokens, TT_CURLY_BRACES_OPEN) {
 		return Definition{
@@ -398,6 +831,7 @@ func led(tokens *TokenQueue, node Node, ateWS bool) Node {
 			convertToIdentifierList(right),
 			parseProgram(tokens, TT_CURLY_BRACES_CLOSE),
 			left.getPosition(),
+			false,
 		}
 	}
 	if !ateWS {
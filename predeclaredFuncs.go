@@ -1,23 +1,35 @@
-package main
+package trex
 
 import (
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
-var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
-	"len": func(input Value, params ListValue, pos Position) Value {
+// predeclaredValues are identifiers which are bound to values rather than to definitions.
+var predeclaredValues = map[string]Value{
+	"true":  BoolValue{true},
+	"false": BoolValue{false},
+	"null":  NullValue{},
+}
+
+var predeclaredFuncs = map[string]func(*Environment, Value, ListValue, Position) Value{
+	"len": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return StringValue{strconv.Itoa(len(input.String()))}
 	},
-	"count": func(input Value, params ListValue, pos Position) Value {
+	"count": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
-		return StringValue{strconv.Itoa(len(valAsList(input).vals))}
+		count := 0
+		next := iterAsList(input)
+		for _, ok := next(); ok; _, ok = next() {
+			count++
+		}
+		return StringValue{strconv.Itoa(count)}
 	},
-	"split": func(input Value, params ListValue, pos Position) Value {
+	"split": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		ret := ListValue{}
 		for _, i := range strings.Split(input.String(), params.vals[0].String()) {
@@ -25,23 +37,15 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return ret
 	},
-	"lines": func(input Value, params ListValue, pos Position) Value {
+	"lines": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
-		ret := ListValue{}
-		for _, i := range strings.Split(input.String(), "\n") {
-			ret.vals = append(ret.vals, StringValue{i})
-		}
-		return ret
+		return splitSequence(env, input.String(), "\n", pos)
 	},
-	"words": func(input Value, params ListValue, pos Position) Value {
+	"words": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
-		ret := ListValue{}
-		for _, i := range strings.Fields(input.String()) {
-			ret.vals = append(ret.vals, StringValue{i})
-		}
-		return ret
+		return fieldsSequence(env, input.String(), pos)
 	},
-	"chars": func(input Value, params ListValue, pos Position) Value {
+	"chars": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := []rune(input.String())
 		ret := ListValue{make([]Value, len(str))}
@@ -51,33 +55,33 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return ret
 	},
-	"min": func(input Value, params ListValue, pos Position) Value {
+	"min": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		var min Value
-		var minVal int
+		var minVal number
 		for _, i := range valAsList(input).vals {
-			currVal := atoi(callDefinition(params.vals[0], i, ListValue{}, pos).String(), pos)
-			if min == nil || currVal < minVal {
+			currVal := parseNumber(callDefinition(env, params.vals[0], i, ListValue{}, pos).String(), pos)
+			if min == nil || compareNumbers(currVal, minVal) < 0 {
 				min = i
 				minVal = currVal
 			}
 		}
 		return min
 	},
-	"max": func(input Value, params ListValue, pos Position) Value {
+	"max": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		var max Value
-		var maxVal int
+		var maxVal number
 		for _, i := range valAsList(input).vals {
-			currVal := atoi(callDefinition(params.vals[0], i, ListValue{}, pos).String(), pos)
-			if max == nil || currVal > maxVal {
+			currVal := parseNumber(callDefinition(env, params.vals[0], i, ListValue{}, pos).String(), pos)
+			if max == nil || compareNumbers(currVal, maxVal) > 0 {
 				max = i
 				maxVal = currVal
 			}
 		}
 		return max
 	},
-	"unique": func(input Value, params ListValue, pos Position) Value {
+	"unique": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		ret := ListValue{}
 		for _, i := range valAsList(input).vals {
@@ -94,7 +98,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return ret
 	},
-	"numoccurs": func(input Value, params ListValue, pos Position) Value {
+	"numoccurs": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		count := 0
 		vals := valAsList(input).vals
@@ -109,19 +113,19 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return StringValue{strconv.Itoa(count)}
 	},
-	"toupper": func(input Value, params ListValue, pos Position) Value {
+	"toupper": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return StringValue{strings.ToUpper(input.String())}
 	},
-	"tolower": func(input Value, params ListValue, pos Position) Value {
+	"tolower": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return StringValue{strings.ToLower(input.String())}
 	},
-	"isletter": func(input Value, params ListValue, pos Position) Value {
+	"isletter": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return createBoolValue(len([]rune(input.String())) == 1 && unicode.IsLetter([]rune(input.String())[0]))
 	},
-	"isupper": func(input Value, params ListValue, pos Position) Value {
+	"isupper": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -131,7 +135,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"islower": func(input Value, params ListValue, pos Position) Value {
+	"islower": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -141,11 +145,11 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"isdigit": func(input Value, params ListValue, pos Position) Value {
+	"isdigit": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return createBoolValue(len([]rune(input.String())) == 1 && unicode.IsDigit([]rune(input.String())[0]))
 	},
-	"ascii": func(input Value, params ListValue, pos Position) Value {
+	"ascii": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		vals := ListValue{}
 		for _, i := range []rune(input.String()) {
@@ -156,23 +160,85 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return vals
 	},
-	"matches": func(input Value, params ListValue, pos Position) Value {
+	"matches": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
-		r := regexp.MustCompile(params.vals[0].String())
-		matches := r.FindAllString(input.String(), -1)
+		r := env.regexParam(params, 0, pos)
+		return matchSequence(env, r, input.String(), pos)
+	},
+	"hasmatch": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		r := env.regexParam(params, 0, pos)
+		return createBoolValue(r.MatchString(input.String()))
+	},
+	"captures": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
-		ret := ListValue{make([]Value, len(matches))}
-		for i := 0; i < len(matches); i++ {
-			ret.vals[i] = StringValue{matches[i]}
+		r := env.regexParam(params, 0, pos)
+		ret := ListValue{}
+		for _, groups := range r.FindAllStringSubmatch(input.String(), -1) {
+			env.step(pos)
+			ret.vals = append(ret.vals, stringList(groups[1:]))
+			env.checkListLen(len(ret.vals), pos)
 		}
 		return ret
 	},
-	"hasmatch": func(input Value, params ListValue, pos Position) Value {
+	"named": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
-		r := regexp.MustCompile(params.vals[0].String())
-		return createBoolValue(r.MatchString(input.String()))
+		r := env.regexParam(params, 0, pos)
+		groups := r.FindStringSubmatch(input.String())
+		if groups == nil {
+			return NullValue{}
+		}
+		ret := newMap()
+		for i, name := range r.SubexpNames() {
+			if name != "" {
+				ret.set(name, StringValue{groups[i]})
+			}
+		}
+		return ret
 	},
-	"join": func(input Value, params ListValue, pos Position) Value {
+	"resub": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(2, params, pos)
+		r := env.regexParam(params, 0, pos)
+		str := input.String()
+		switch repl := params.vals[1].(type) {
+		case DefinitionValue, PredeclaredDefinitionValue:
+			// the groups are only passed to definitions which take parameters, so that
+			// definitions such as toupper can be used as they are
+			withGroups := false
+			if def, ok := repl.(DefinitionValue); ok {
+				_, high := def.def.params.arity()
+				withGroups = high != 0
+			}
+			var b strings.Builder
+			last := 0
+			for _, idx := range r.FindAllStringSubmatchIndex(str, -1) {
+				groups := ListValue{}
+				if withGroups {
+					strs := make([]string, len(idx)/2-1)
+					for i := range strs {
+						if idx[2*i+2] >= 0 {
+							strs[i] = str[idx[2*i+2]:idx[2*i+3]]
+						}
+					}
+					groups = stringList(strs)
+				}
+				b.WriteString(str[last:idx[0]])
+				b.WriteString(callDefinition(env, repl, StringValue{str[idx[0]:idx[1]]}, groups, pos).String())
+				env.checkStringLen(b.Len(), pos)
+				last = idx[1]
+			}
+			b.WriteString(str[last:])
+			return StringValue{b.String()}
+		default:
+			return StringValue{r.ReplaceAllString(str, repl.String())}
+		}
+	},
+	"resplit": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		r := env.regexParam(params, 0, pos)
+		return stringList(r.Split(input.String(), -1))
+	},
+	"join": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		ret := StringValue{}
 		for _, i := range valAsList(input).vals {
@@ -180,7 +246,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return ret
 	},
-	"fold": func(input Value, params ListValue, pos Position) Value {
+	"fold": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		v := valAsList(input)
 		if len(v.vals) == 0 {
@@ -189,11 +255,11 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		ret := v.vals[len(v.vals)-1]
 		for i := len(v.vals) - 2; i >= 0; i-- {
 			list := ListValue{[]Value{v.vals[i], ret}}
-			ret = callDefinition(params.vals[0], input, list, pos)
+			ret = callDefinition(env, params.vals[0], input, list, pos)
 		}
 		return ret
 	},
-	"foldr": func(input Value, params ListValue, pos Position) Value {
+	"foldr": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		v := valAsList(input)
 		if len(v.vals) == 0 {
@@ -202,11 +268,11 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		ret := v.vals[len(v.vals)-1]
 		for i := len(v.vals) - 2; i >= 0; i-- {
 			list := ListValue{[]Value{v.vals[i], ret}}
-			ret = callDefinition(params.vals[0], input, list, pos)
+			ret = callDefinition(env, params.vals[0], input, list, pos)
 		}
 		return ret
 	},
-	"foldl": func(input Value, params ListValue, pos Position) Value {
+	"foldl": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		v := valAsList(input)
 		if len(v.vals) == 0 {
@@ -215,21 +281,22 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		ret := v.vals[0]
 		for i := 1; i < len(v.vals); i++ {
 			list := ListValue{[]Value{ret, v.vals[i]}}
-			ret = callDefinition(params.vals[0], input, list, pos)
+			ret = callDefinition(env, params.vals[0], input, list, pos)
 		}
 		return ret
 	},
-	"sort": func(input Value, params ListValue, pos Position) Value {
+	"sort": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
-		v := valAsList(input)
+		// the input may be shared with other values, so it is sorted as a copy
+		v := ListValue{append([]Value{}, valAsList(input).vals...)}
 		sort.SliceStable(v.vals, func(i, j int) bool {
-			a := atoi(callDefinition(params.vals[0], v.vals[i], ListValue{}, pos).String(), pos)
-			b := atoi(callDefinition(params.vals[0], v.vals[j], ListValue{}, pos).String(), pos)
-			return a < b
+			a := parseNumber(callDefinition(env, params.vals[0], v.vals[i], ListValue{}, pos).String(), pos)
+			b := parseNumber(callDefinition(env, params.vals[0], v.vals[j], ListValue{}, pos).String(), pos)
+			return compareNumbers(a, b) < 0
 		})
-		return input
+		return v
 	},
-	"reverse": func(input Value, params ListValue, pos Position) Value {
+	"reverse": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		v := valAsList(input)
 		if len(v.vals) == 1 {
@@ -239,33 +306,39 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 			}
 			return StringValue{string(r)}
 		} else {
-			for i := len(v.vals)/2 - 1; i >= 0; i-- {
-				opp := len(v.vals) - 1 - i
-				v.vals[i], v.vals[opp] = v.vals[opp], v.vals[i]
+			ret := ListValue{make([]Value, len(v.vals))}
+			for i, e := range v.vals {
+				ret.vals[len(v.vals)-1-i] = e
 			}
-			return v
+			return ret
 		}
 	},
-	"replace": func(input Value, params ListValue, pos Position) Value {
+	"replace": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(2, params, pos)
 		return StringValue{strings.ReplaceAll(input.String(), params.vals[0].String(), params.vals[1].String())}
 	},
-	"bool": func(input Value, params ListValue, pos Position) Value {
+	"bool": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
-		if input.String() != "" {
-			return StringValue{"true"}
-		}
-		return StringValue{"false"}
+		return createBoolValue(isTrue(input))
 	},
-	"startswith": func(input Value, params ListValue, pos Position) Value {
+	"isnull": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		_, ok := input.(NullValue)
+		return createBoolValue(ok)
+	},
+	"isempty": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		return createBoolValue(isEmpty(input))
+	},
+	"startswith": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return createBoolValue(strings.HasPrefix(input.String(), params.vals[0].String()))
 	},
-	"endswith": func(input Value, params ListValue, pos Position) Value {
+	"endswith": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return createBoolValue(strings.HasSuffix(input.String(), params.vals[0].String()))
 	},
-	"isalnum": func(input Value, params ListValue, pos Position) Value {
+	"isalnum": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -275,7 +348,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"isalpha": func(input Value, params ListValue, pos Position) Value {
+	"isalpha": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -285,7 +358,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"isnum": func(input Value, params ListValue, pos Position) Value {
+	"isnum": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -295,7 +368,7 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"isspace": func(input Value, params ListValue, pos Position) Value {
+	"isspace": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		for _, r := range []rune(str) {
@@ -305,12 +378,12 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 		}
 		return createBoolValue(str != "")
 	},
-	"istitle": func(input Value, params ListValue, pos Position) Value {
+	"istitle": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		str := input.String()
 		return createBoolValue(str != "" && strings.Title(strings.ToLower(str)) == str)
 	},
-	"swapcase": func(input Value, params ListValue, pos Position) Value {
+	"swapcase": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return StringValue{strings.Map(func(r rune) rune {
 			if unicode.IsLower(r) {
@@ -322,28 +395,188 @@ var predeclaredFuncs = map[string]func(Value, ListValue, Position) Value{
 			}
 		}, input.String())}
 	},
-	"totitle": func(input Value, params ListValue, pos Position) Value {
+	"totitle": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(0, params, pos)
 		return StringValue{strings.Title(input.String())}
 	},
-	"indexof": func(input Value, params ListValue, pos Position) Value {
+	"indexof": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return StringValue{strconv.Itoa(strings.Index(input.String(), params.vals[0].String()))}
 	},
-	"lastindexof": func(input Value, params ListValue, pos Position) Value {
+	"lastindexof": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return StringValue{strconv.Itoa(strings.LastIndex(input.String(), params.vals[0].String()))}
 	},
-	"indexby": func(input Value, params ListValue, pos Position) Value {
+	"indexby": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return StringValue{strconv.Itoa(strings.IndexFunc(input.String(), func(r rune) bool {
-			return callDefinition(params.vals[0], StringValue{string(r)}, ListValue{}, pos).String() != ""
+			return isTrue(callDefinition(env, params.vals[0], StringValue{string(r)}, ListValue{}, pos))
 		}))}
 	},
-	"lastindexby": func(input Value, params ListValue, pos Position) Value {
+	"lastindexby": func(env *Environment, input Value, params ListValue, pos Position) Value {
 		assertParamsNum(1, params, pos)
 		return StringValue{strconv.Itoa(strings.LastIndexFunc(input.String(), func(r rune) bool {
-			return callDefinition(params.vals[0], StringValue{string(r)}, ListValue{}, pos).String() != ""
+			return isTrue(callDefinition(env, params.vals[0], StringValue{string(r)}, ListValue{}, pos))
 		}))}
 	},
+	"take": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		n := atoi(params.vals[0].String(), pos)
+		return SequenceValue{func() iterator {
+			next, i := iterAsList(input), 0
+			return func() (Value, bool) {
+				if i >= n {
+					return nil, false
+				}
+				i++
+				return next()
+			}
+		}}
+	},
+	"first": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		if v, ok := iterAsList(input)(); ok {
+			return v
+		}
+		return NullValue{}
+	},
+	"any": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		next := iterAsList(input)
+		for v, ok := next(); ok; v, ok = next() {
+			if isTrue(callDefinition(env, params.vals[0], v, ListValue{}, pos)) {
+				return createBoolValue(true)
+			}
+		}
+		return createBoolValue(false)
+	},
+	"keys": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		m := assertMap(input, pos)
+		ret := ListValue{make([]Value, len(m.keys))}
+		for i, k := range m.keys {
+			ret.vals[i] = StringValue{k}
+		}
+		return ret
+	},
+	"values": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		m := assertMap(input, pos)
+		ret := ListValue{make([]Value, len(m.keys))}
+		for i, k := range m.keys {
+			ret.vals[i] = m.vals[k]
+		}
+		return ret
+	},
+	"pairs": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		m := assertMap(input, pos)
+		ret := ListValue{make([]Value, len(m.keys))}
+		for i, k := range m.keys {
+			ret.vals[i] = ListValue{[]Value{StringValue{k}, m.vals[k]}}
+		}
+		return ret
+	},
+	"groupby": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		ret := newMap()
+		for _, i := range valAsList(input).vals {
+			key := callDefinition(env, params.vals[0], i, ListValue{}, pos).String()
+			group, _ := ret.vals[key].(ListValue)
+			ret.set(key, ListValue{append(group.vals, i)})
+		}
+		return ret
+	},
+	"counts": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		ret := newMap()
+		counts := map[string]int{}
+		for _, i := range valAsList(input).vals {
+			counts[i.String()]++
+			ret.set(i.String(), StringValue{strconv.Itoa(counts[i.String()])})
+		}
+		return ret
+	},
+	"csv": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsRange(0, 1, params, pos)
+		return readCSV(env, input.String(), ',', len(params.vals) == 1 && isTrue(params.vals[0]), pos)
+	},
+	"tsv": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsRange(0, 1, params, pos)
+		return readCSV(env, input.String(), '\t', len(params.vals) == 1 && isTrue(params.vals[0]), pos)
+	},
+	"fromcsv": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsRange(1, 2, params, pos)
+		return readCSV(env, input.String(), csvSeparator(params.vals[0], pos), len(params.vals) == 2 && isTrue(params.vals[1]), pos)
+	},
+	"tocsv": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsRange(0, 1, params, pos)
+		sep := ','
+		if len(params.vals) == 1 {
+			sep = csvSeparator(params.vals[0], pos)
+		}
+		return StringValue{writeCSV(valAsList(input), sep, pos)}
+	},
+	"fromjson": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		return readJSON(env, input.String(), pos)
+	},
+	"tojson": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		return StringValue{writeJSON(env, input, pos)}
+	},
+	"get": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		return getPath(input, params.vals[0].String(), pos)
+	},
+	"now": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		return timeValue(time.Now())
+	},
+	"parsetime": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsRange(0, 1, params, pos)
+		layout := time.RFC3339Nano
+		if len(params.vals) == 1 {
+			layout = params.vals[0].String()
+		}
+		return parseTime(env, input.String(), layout, pos)
+	},
+	"formattime": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		t := toTime(input, pos)
+		if layout := params.vals[0].String(); layout != "unix" {
+			return StringValue{formatTime(env, t, layout, pos)}
+		}
+		return formatSeconds(t.Unix(), int64(t.Nanosecond()))
+	},
+	"unixtime": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(0, params, pos)
+		t := toTime(input, pos)
+		return formatSeconds(t.Unix(), int64(t.Nanosecond()))
+	},
+	"timediff": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		t, from := toTime(input, pos), toTime(params.vals[0], env.paramPosition(0, pos))
+		return formatSeconds(t.Unix()-from.Unix(), int64(t.Nanosecond()-from.Nanosecond()))
+	},
+	"truncatetime": func(env *Environment, input Value, params ListValue, pos Position) Value {
+		assertParamsNum(1, params, pos)
+		return timeValue(truncateTime(env, toTime(input, pos), params.vals[0].String(), pos))
+	},
+}
+
+// stringList returns a list of the given strings.
+func stringList(strs []string) ListValue {
+	ret := ListValue{make([]Value, len(strs))}
+	for i, str := range strs {
+		ret.vals[i] = StringValue{str}
+	}
+	return ret
+}
+
+func assertMap(val Value, pos Position) MapValue {
+	if m, ok := val.(MapValue); ok {
+		return m
+	}
+	panic(newErr(E_WRONG_TYPE, "expected a map", pos))
 }
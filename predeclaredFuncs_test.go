@@ -0,0 +1,43 @@
+package trex
+
+import "testing"
+
+func TestSortAndReverse(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"sort(#len) ('three', 'one', 'four')", "one, four, three"},
+		{"sort(#len) ('bb', 'a', 'cc', 'd')", "a, d, bb, cc"},
+		{"sort(-> 0 - []) (1, 3, 2)", "3, 2, 1"},
+		{"sort(#len) ()", ""},
+		{"reverse (1, 2, 3, 4)", "4, 3, 2, 1"},
+		{"reverse 1234", "4321"},
+		{"reverse 'héllo'", "olléh"},
+		{"reverse (i for i in 0..3)", "2, 1, 0"},
+	}
+	for _, test := range tests {
+		val, err := NewEngine().Eval(test.code, "")
+		if err != nil || val.String() != test.want {
+			t.Errorf("%q = %v, %v, want %s", test.code, val, err, test.want)
+		}
+	}
+}
+
+func TestValuesAreNotMutated(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"id => []\n(sort(#id) b) << \" | \" << b where b = (3, 1, 2)", "1, 2, 3 | 3, 1, 2"},
+		{"(reverse b) << \" | \" << b where b = (3, 1, 2)", "2, 1, 3 | 3, 1, 2"},
+		{"id => []\nsorted(l) => sort(#id) l\nsorted(b) << \" | \" << sorted(b) << \" | \" << b where b = (2, 1)", "1, 2 | 1, 2 | 2, 1"},
+		{"id => []\nmemo nums => (3, 1, 2)\n(sort(#id) nums) << \" | \" << nums", "1, 2, 3 | 3, 1, 2"},
+	}
+	for _, test := range tests {
+		val, err := NewEngine().Eval(test.code, "")
+		if err != nil || val.String() != test.want {
+			t.Errorf("%q = %v, %v, want %s", test.code, val, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package trex
+
+import (
+	"container/list"
+	"regexp"
+)
+
+// regexCacheSize is the number of compiled regular expressions which an environment keeps.
+const regexCacheSize = 64
+
+// regexCache holds the regular expressions which were compiled most recently, so that
+// calling a built-in definition with the same pattern many times compiles it only once.
+type regexCache struct {
+	// order holds the cached expressions, from the most to the least recently used.
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// regexParam compiles the i-th parameter of the current call to a built-in definition as
+// a regular expression. An invalid expression is reported at the parameter.
+func (env *Environment) regexParam(params ListValue, i int, pos Position) *regexp.Regexp {
+	pattern := params.vals[i].String()
+	if env.regexes == nil {
+		env.regexes = &regexCache{list.New(), map[string]*list.Element{}}
+	}
+	c := env.regexes
+	if elem, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*regexp.Regexp)
+	}
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(newErr(E_INVALID_PATTERN, "invalid regular expression: "+err.Error(), env.paramPosition(i, pos)))
+	}
+	c.entries[pattern] = c.order.PushFront(r)
+	if c.order.Len() > regexCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexp.Regexp).String())
+	}
+	return r
+}
+
+// paramPosition returns the position of the i-th parameter of the running call to a
+// built-in definition, or pos if it is not known.
+func (env *Environment) paramPosition(i int, pos Position) Position {
+	if i < len(env.builtinParams) {
+		return env.builtinParams[i].getPosition()
+	}
+	return pos
+}
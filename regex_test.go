@@ -0,0 +1,55 @@
+package trex
+
+import "testing"
+
+func TestResub(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`resub('(\w+)@(\w+)', '$2 at $1') 'bob@host, ann@box'`, "host at bob, box at ann"},
+		{`resub('(?P<user>\w+)@\w+', '${user}') 'bob@host'`, "bob"},
+		{`resub('\d+', -> [] * 2) 'a1 b20'`, "a2 b40"},
+		{`resub('(o)', #toupper) 'foo'`, "fOO"},
+		{"up => toupper []\nresub('(o)(o)', #up) 'foo'", "fOO"},
+		{`resub('(\w+)@(\w+)', ((user, host) -> toupper user << '@' << host)) 'bob@host'`, "BOB@host"},
+		{`resub('(a)|(b)', ((a, b) -> b << a)) 'ab'`, "ab"},
+		{`resub('x', #toupper) 'abc'`, "abc"},
+	}
+	for _, test := range tests {
+		val, err := NewEngine().Eval(test.code, "")
+		if err != nil || val.String() != test.want {
+			t.Errorf("%q = %v, %v, want %s", test.code, val, err, test.want)
+		}
+	}
+	_, err := NewEngine().Eval(`resub('(a)(b)', (x -> x)) 'ab'`, "")
+	if errs := Errors(err); len(errs) != 1 || errs[0].Code() != E_PARAM_COUNT {
+		t.Errorf("a definition taking the wrong number of groups returned %v, want an error with code %v", err, E_PARAM_COUNT)
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		code string
+		want ErrorCode
+	}{
+		{`matches('(') 'a'`, E_INVALID_PATTERN},
+		{`resub('[a', 'b') 'a'`, E_INVALID_PATTERN},
+		{`p => '*'` + "\n" + `resplit(p) 'a'`, E_INVALID_PATTERN},
+		{`[] match { re"(" -> 1 }`, E_INVALID_REGEX},
+	}
+	for _, test := range tests {
+		_, err := NewEngine().Eval(test.code, "")
+		errs := Errors(err)
+		if len(errs) != 1 || errs[0].Code() != test.want {
+			t.Errorf("%q returned %v, want an error with code %v", test.code, err, test.want)
+			continue
+		}
+		if want := test.want.Type(); errs[0].Type() != want {
+			t.Errorf("%q returned an error of type %v, want %v", test.code, errs[0].Type(), want)
+		}
+	}
+	if E_INVALID_PATTERN.Type() != ERR_INTERPRETER {
+		t.Errorf("%v is not a runtime error", E_INVALID_PATTERN)
+	}
+}
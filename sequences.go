@@ -0,0 +1,261 @@
+package trex
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SequenceValue is a list whose values are only computed when they are needed, such as
+// the values of a range or of a comprehension. Reading a single value of a sequence only
+// computes the values before it, so a sequence can be much longer than would fit in memory.
+type SequenceValue struct {
+	iterate func() iterator
+}
+
+// iterator returns the next value of a sequence, or false once there are no more values.
+type iterator func() (Value, bool)
+
+func (this SequenceValue) String() string {
+	return this.list().String()
+}
+
+// list computes all the values of the sequence.
+func (this SequenceValue) list() ListValue {
+	ret := ListValue{}
+	next := this.iterate()
+	for v, ok := next(); ok; v, ok = next() {
+		ret.vals = append(ret.vals, v)
+	}
+	return ret
+}
+
+// cachedSequence returns a sequence of the values returned by next. Each value is only
+// computed once, no matter how many times the sequence is read. pos is the position of
+// the expression which created the sequence.
+func cachedSequence(env *Environment, pos Position, next iterator) SequenceValue {
+	vals := []Value{}
+	done := false
+	return SequenceValue{func() iterator {
+		i := 0
+		return func() (Value, bool) {
+			if i == len(vals) {
+				if done {
+					return nil, false
+				}
+				// computing a value may read other sequences, and so on, which nests
+				// like calls do and counts towards the same limit
+				env.checkDepth(pos)
+				env.calls = append(env.calls, Frame{"<sequence>", pos})
+				v, ok := next()
+				env.calls = env.calls[:len(env.calls)-1]
+				if !ok {
+					done = true
+					return nil, false
+				}
+				env.checkListLen(len(vals)+1, pos)
+				vals = append(vals, v)
+			}
+			i++
+			return vals[i-1], true
+		}
+	}}
+}
+
+func sliceIterator(vals []Value) iterator {
+	i := 0
+	return func() (Value, bool) {
+		if i == len(vals) {
+			return nil, false
+		}
+		i++
+		return vals[i-1], true
+	}
+}
+
+// iterAsList returns an iterator over the values which valAsList would return.
+func iterAsList(val Value) iterator {
+	if seq, ok := val.(SequenceValue); ok {
+		return seq.iterate()
+	}
+	return sliceIterator(valAsList(val).vals)
+}
+
+// iterToList returns an iterator over the values which valToList would return.
+func iterToList(val Value) iterator {
+	if seq, ok := val.(SequenceValue); ok {
+		return seq.iterate()
+	}
+	return sliceIterator(valToList(val))
+}
+
+// force computes the values of all the sequences in val, which may be nested in lists
+// and maps, and returns val with the sequences replaced by lists.
+func force(val Value) Value {
+	switch v := val.(type) {
+	case SequenceValue:
+		return force(v.list())
+	case ListValue:
+		ret := ListValue{make([]Value, len(v.vals))}
+		for i, e := range v.vals {
+			ret.vals[i] = force(e)
+		}
+		return ret
+	case MapValue:
+		ret := MapValue{v.keys, make(map[string]Value, len(v.vals))}
+		for k, e := range v.vals {
+			ret.vals[k] = force(e)
+		}
+		return ret
+	default:
+		return val
+	}
+}
+
+// rangeSequence returns the numbers from low to high, excluding high. pos is the
+// position of the range.
+func rangeSequence(env *Environment, low, high int64, pos Position) SequenceValue {
+	return SequenceValue{func() iterator {
+		i := low
+		return func() (Value, bool) {
+			if i >= high {
+				return nil, false
+			}
+			env.step(pos)
+			env.checkListLen(int(i-low)+1, pos)
+			i++
+			return StringValue{strconv.FormatInt(i-1, 10)}, true
+		}
+	}}
+}
+
+// splitSequence returns the parts of str between the occurrences of sep.
+func splitSequence(env *Environment, str string, sep string, pos Position) SequenceValue {
+	done := false
+	return cachedSequence(env, pos, func() (Value, bool) {
+		if done {
+			return nil, false
+		}
+		idx := strings.Index(str, sep)
+		if idx < 0 {
+			done = true
+			return StringValue{str}, true
+		}
+		part := str[:idx]
+		str = str[idx+len(sep):]
+		return StringValue{part}, true
+	})
+}
+
+// fieldsSequence returns the words of str, which are separated by whitespace.
+func fieldsSequence(env *Environment, str string, pos Position) SequenceValue {
+	return cachedSequence(env, pos, func() (Value, bool) {
+		start := strings.IndexFunc(str, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			return nil, false
+		}
+		str = str[start:]
+		end := strings.IndexFunc(str, unicode.IsSpace)
+		if end < 0 {
+			end = len(str)
+		}
+		word := str[:end]
+		str = str[end:]
+		return StringValue{word}, true
+	})
+}
+
+// matchSequence returns the matches of r in str. Matches are searched for in batches
+// which double in size, so that finding the first few matches of a long string is cheap.
+func matchSequence(env *Environment, r *regexp.Regexp, str string, pos Position) SequenceValue {
+	var matches []string
+	i, batch := 0, 1
+	return cachedSequence(env, pos, func() (Value, bool) {
+		if i == len(matches) {
+			if len(matches) < batch/2 {
+				return nil, false
+			}
+			matches = r.FindAllString(str, batch)
+			batch *= 2
+			if i == len(matches) {
+				return nil, false
+			}
+		}
+		i++
+		return StringValue{matches[i-1]}, true
+	})
+}
+
+// iterate returns an iterator over the values of the comprehension. The values of the
+// lists it goes over are bound in a scope whose parent is the scope the comprehension was
+// evaluated in, so that the comprehension can still be read after that scope was exited.
+// The scope is reused for the next value, unless a value of the comprehension may refer
+// to it (as closures and sequences do), so that such values keep seeing the values they
+// were created with.
+func (this Comprehension) iterate(env *Environment, input Value, parent *scope, idx int) iterator {
+	var vals, inner iterator
+	var block *scope
+	id := this.fors[idx].id.id
+	return func() (Value, bool) {
+		caller := env.scope
+		defer func() { env.scope = caller }()
+		if vals == nil {
+			env.scope = parent
+			vals = iterToList(this.fors[idx].exp.interpret(env, input))
+		}
+		for {
+			if inner != nil {
+				if v, ok := inner(); ok {
+					if capturesScope(v) {
+						block = nil
+					}
+					return v, true
+				}
+				inner = nil
+			}
+			v, ok := vals()
+			if !ok {
+				return nil, false
+			}
+			env.step(this.pos)
+			if block == nil {
+				// no definitions can be made in the scope, so it has no map for them
+				block = &scope{nil, map[string]Value{}, parent}
+			}
+			block.values[id] = v
+			env.scope = block
+			if idx+1 < len(this.fors) {
+				inner = this.iterate(env, input, block, idx+1)
+			} else if this.where == nil || isTrue(this.where.interpret(env, input)) {
+				ret := this.exp.interpret(env, input)
+				if capturesScope(ret) {
+					block = nil
+				}
+				return ret, true
+			}
+		}
+	}
+}
+
+// capturesScope reports whether val may refer to the scope it was created in, which is
+// the case for definitions and sequences, and for lists and maps which hold them.
+func capturesScope(val Value) bool {
+	switch v := val.(type) {
+	case DefinitionValue, SequenceValue:
+		return true
+	case ListValue:
+		for _, e := range v.vals {
+			if capturesScope(e) {
+				return true
+			}
+		}
+	case MapValue:
+		for _, e := range v.vals {
+			if capturesScope(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package trex
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Times are strings in the format of RFC 3339, such as "2024-03-01T12:30:00Z", with a
+// fraction of a second only if it is not whole. They keep the time zone they were
+// read in.
+
+func timeValue(t time.Time) StringValue {
+	return StringValue{t.Format(time.RFC3339Nano)}
+}
+
+// toTime reads val, which must be a time.
+func toTime(val Value, pos Position) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, val.String())
+	if err != nil {
+		panic(conversionError(val.String(), "a time", pos).withHint("use parsetime to read times which are written differently"))
+	}
+	return t
+}
+
+// strftimeComposites are the strftime directives which stand for several others.
+var strftimeComposites = map[byte]string{'F': "%Y-%m-%d", 'T': "%H:%M:%S", 'D': "%m/%d/%y"}
+
+// strftimeLayouts are the Go layouts of the other strftime directives which are
+// supported, apart from %f and %%. Each directive is formatted on its own, so that the
+// text around it is never mistaken for part of a Go layout.
+var strftimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'z': "-0700", 'Z': "MST",
+}
+
+// strftimePart is either a directive of a strftime layout, or the text between two
+// directives if directive is 0.
+type strftimePart struct {
+	directive byte
+	text      string
+}
+
+// strftimeParts splits layout, a strftime layout such as "%Y-%m-%d", into its parts. The
+// i-th parameter of the running call is the layout, so that errors can point at it.
+func strftimeParts(env *Environment, layout string, i int, pos Position) []strftimePart {
+	parts := []strftimePart{}
+	text := []byte{}
+	for j := 0; j < len(layout); j++ {
+		if layout[j] != '%' {
+			text = append(text, layout[j])
+			continue
+		}
+		j++
+		if j == len(layout) {
+			panic(newErr(E_BUILTIN, "time layout ends with '%'", env.paramPosition(i, pos)))
+		}
+		d := layout[j]
+		if d == '%' {
+			text = append(text, '%')
+			continue
+		}
+		if len(text) > 0 {
+			parts = append(parts, strftimePart{0, string(text)})
+			text = text[:0]
+		}
+		if composite, ok := strftimeComposites[d]; ok {
+			parts = append(parts, strftimeParts(env, composite, i, pos)...)
+			continue
+		}
+		if _, ok := strftimeLayouts[d]; !ok && d != 'f' {
+			panic(newErr(E_BUILTIN, "unsupported directive %"+string(d)+" in time layout", env.paramPosition(i, pos)))
+		}
+		parts = append(parts, strftimePart{d, ""})
+	}
+	if len(text) > 0 {
+		parts = append(parts, strftimePart{0, string(text)})
+	}
+	return parts
+}
+
+// formatTime writes t with layout, which is either a Go layout such as "2006-01-02", or
+// a strftime layout such as "%Y-%m-%d" if it contains a '%'.
+func formatTime(env *Environment, t time.Time, layout string, pos Position) string {
+	if !strings.Contains(layout, "%") {
+		return t.Format(layout)
+	}
+	var b strings.Builder
+	for _, part := range strftimeParts(env, layout, 0, pos) {
+		switch part.directive {
+		case 0:
+			b.WriteString(part.text)
+		case 'f':
+			b.WriteString(strconv.Itoa(t.Nanosecond()/1000 + 1e6)[1:])
+		default:
+			b.WriteString(t.Format(strftimeLayouts[part.directive]))
+		}
+	}
+	return b.String()
+}
+
+// parseTime reads str as a time written with the given layout, which is either a Go
+// layout, a strftime layout, or "unix" for a number of seconds since the Unix epoch.
+// Times without a time zone are in UTC.
+func parseTime(env *Environment, str string, layout string, pos Position) StringValue {
+	if layout == "unix" {
+		sec, nsec, ok := parseSeconds(str)
+		if !ok {
+			panic(conversionError(str, "a number of seconds", pos))
+		}
+		return timeValue(time.Unix(sec, nsec).UTC())
+	}
+	var t time.Time
+	var err error
+	if strings.Contains(layout, "%") {
+		t, err = parseStrftime(str, strftimeParts(env, layout, 0, pos))
+	} else {
+		t, err = time.Parse(layout, str)
+	}
+	if err != nil {
+		panic(conversionError(str, "a time with the layout "+strconv.Quote(layout), pos))
+	}
+	return timeValue(t)
+}
+
+// parseStrftime reads str as a time written with the parts of a strftime layout. Like
+// time.Parse, fields which are not in the layout are zero (or one, for the month and the
+// day), names of months and days are read regardless of case, and days of the week are
+// checked but otherwise ignored. The only abbreviations of time zones which are read are
+// UTC, GMT and those of the local time zone.
+func parseStrftime(str string, parts []strftimePart) (time.Time, error) {
+	invalid := errors.New("the time does not match the layout")
+	year, month, day, hour, min, sec, nsec := 0, 1, 1, 0, 0, 0, 0
+	pm, am := false, false
+	loc := time.UTC
+	rest := str
+	for _, part := range parts {
+		var n int
+		var ok bool
+		switch part.directive {
+		case 0:
+			if !strings.HasPrefix(rest, part.text) {
+				return time.Time{}, invalid
+			}
+			rest = rest[len(part.text):]
+			continue
+		case 'Y':
+			n, rest, ok = parseDigits(rest, 4, 4)
+			year = n
+		case 'y':
+			n, rest, ok = parseDigits(rest, 2, 2)
+			year = 2000 + n
+			if n >= 69 {
+				year = 1900 + n
+			}
+		case 'm':
+			n, rest, ok = parseDigits(rest, 1, 2)
+			month = n
+		case 'd', 'e':
+			if part.directive == 'e' {
+				rest = strings.TrimPrefix(rest, " ")
+			}
+			n, rest, ok = parseDigits(rest, 1, 2)
+			day = n
+		case 'H', 'I':
+			n, rest, ok = parseDigits(rest, 1, 2)
+			hour = n
+			ok = ok && (part.directive == 'H' || 1 <= n && n <= 12)
+		case 'M':
+			n, rest, ok = parseDigits(rest, 1, 2)
+			min = n
+		case 'S':
+			n, rest, ok = parseDigits(rest, 1, 2)
+			sec = n
+		case 'f':
+			digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+			n, rest, ok = parseDigits(rest, 1, 9)
+			for ; ok && digits < 9; digits++ {
+				n *= 10
+			}
+			nsec = n
+		case 'p':
+			if len(rest) >= 2 {
+				am, pm = strings.EqualFold(rest[:2], "AM"), strings.EqualFold(rest[:2], "PM")
+				rest, ok = rest[2:], am || pm
+			}
+		case 'z':
+			loc, rest, ok = parseZoneOffset(rest)
+		default:
+			// names are read by Go, which knows them
+			word := rest[:len(rest)-len(strings.TrimLeftFunc(rest, unicode.IsLetter))]
+			t, err := time.Parse(strftimeLayouts[part.directive], word)
+			rest, ok = rest[len(word):], err == nil && word != ""
+			switch part.directive {
+			case 'b', 'B':
+				month = int(t.Month())
+			case 'Z':
+				// Go makes up a zone without an offset for abbreviations it doesn't
+				// know, so only those whose offset is known are accepted
+				switch {
+				case word == "UTC" || word == "GMT":
+					loc = time.UTC
+				case t.Location() == time.Local:
+					loc = time.Local
+				default:
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			return time.Time{}, invalid
+		}
+	}
+	if rest != "" {
+		return time.Time{}, invalid
+	}
+	if pm && hour < 12 {
+		hour += 12
+	} else if am && hour == 12 {
+		hour = 0
+	}
+	t := time.Date(year, time.Month(month), day, hour, min, sec, nsec, loc)
+	// time.Date normalizes values which are out of range, such as the 31st of April
+	if month < 1 || month > 12 || t.Day() != day || hour > 23 || min > 59 || sec > 59 {
+		return time.Time{}, invalid
+	}
+	return t, nil
+}
+
+// parseDigits reads a number of at least low and at most high digits from the start of
+// str, and returns it and the rest of str.
+func parseDigits(str string, low int, high int) (int, string, bool) {
+	end := 0
+	for end < len(str) && end < high && '0' <= str[end] && str[end] <= '9' {
+		end++
+	}
+	if end < low {
+		return 0, str, false
+	}
+	n, _ := strconv.Atoi(str[:end])
+	return n, str[end:], true
+}
+
+// parseZoneOffset reads a time zone from the start of str, written as "Z" or as an offset
+// from UTC such as "-0700" or "+05:30", and returns it and the rest of str.
+func parseZoneOffset(str string) (*time.Location, string, bool) {
+	if strings.HasPrefix(str, "Z") {
+		return time.UTC, str[1:], true
+	}
+	if str == "" || (str[0] != '+' && str[0] != '-') {
+		return nil, str, false
+	}
+	hours, rest, ok := parseDigits(str[1:], 2, 2)
+	if !ok || hours > 23 {
+		return nil, str, false
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	mins, rest, ok := parseDigits(rest, 2, 2)
+	if !ok || mins > 59 {
+		return nil, str, false
+	}
+	offset := (hours*60 + mins) * 60
+	if str[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone("", offset), rest, true
+}
+
+// parseSeconds reads str as a number of seconds with 1 to 9 digits after the point, if
+// it has one, and returns the whole seconds and the nanoseconds, which have the same sign.
+func parseSeconds(str string) (int64, int64, bool) {
+	whole, frac := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		whole, frac = str[:i], str[i+1:]
+		if frac == "" {
+			return 0, 0, false
+		}
+	}
+	sec, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || len(frac) > 9 {
+		return 0, 0, false
+	}
+	var nsec int64
+	if frac != "" {
+		if nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil || nsec < 0 || frac[0] == '+' || frac[0] == '-' {
+			return 0, 0, false
+		}
+	}
+	if strings.HasPrefix(whole, "-") {
+		nsec = -nsec
+	}
+	return sec, nsec, true
+}
+
+// formatSeconds returns sec seconds and nsec nanoseconds as a number of seconds, with
+// only as many digits after the point as are needed.
+func formatSeconds(sec int64, nsec int64) StringValue {
+	sec += nsec / 1e9
+	nsec %= 1e9
+	if nsec < 0 {
+		sec--
+		nsec += 1e9
+	}
+	if nsec == 0 {
+		return StringValue{strconv.FormatInt(sec, 10)}
+	}
+	sign := ""
+	if sec < 0 {
+		// sec + nsec/1e9 = -((-sec - 1) + (1e9 - nsec)/1e9)
+		sign, sec, nsec = "-", -sec-1, 1e9-nsec
+	}
+	frac := strings.TrimRight(strconv.FormatInt(nsec+1e9, 10)[1:], "0")
+	return StringValue{sign + strconv.FormatInt(sec, 10) + "." + frac}
+}
+
+// truncateTime rounds t down to a whole unit, which is either a calendar unit from
+// "second" to "year" (weeks start on Monday), or a Go duration such as "15m".
+func truncateTime(env *Environment, t time.Time, unit string, pos Position) time.Time {
+	y, mon, day := t.Date()
+	switch unit {
+	case "second":
+		return t.Truncate(time.Second)
+	case "minute":
+		return t.Truncate(time.Minute)
+	case "hour":
+		return time.Date(y, mon, day, t.Hour(), 0, 0, 0, t.Location())
+	case "day":
+		return time.Date(y, mon, day, 0, 0, 0, 0, t.Location())
+	case "week":
+		return time.Date(y, mon, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(y, mon, 1, 0, 0, 0, 0, t.Location())
+	case "year":
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	d, err := time.ParseDuration(unit)
+	if err != nil || d <= 0 {
+		panic(newErr(E_BUILTIN, "invalid unit of time "+strconv.Quote(unit), env.paramPosition(0, pos)).withNote("units are second, minute, hour, day, week, month, year, or durations such as 15m"))
+	}
+	return t.Truncate(d)
+}
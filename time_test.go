@@ -0,0 +1,118 @@
+package trex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	tm := time.Date(2024, time.March, 5, 7, 8, 9, 123456789, time.FixedZone("", 2*60*60))
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2024-03-05 07:08:09"},
+		{"day %d of 2024", "day 05 of 2024"},
+		{"%H:%M on Monday", "07:08 on Monday"},
+		{"%f", "123456"},
+		{"%S.%f", "09.123456"},
+		{"%F %T", "2024-03-05 07:08:09"},
+		{"%D", "03/05/24"},
+		{"%e|%I|%p|%y", " 5|07|AM|24"},
+		{"%b %B %a %A", "Mar March Tue Tuesday"},
+		{"%z", "+0200"},
+		{"100%% at 15:04", "100% at 15:04"},
+		{"%Y Jan PM MST 05 _2 .000", "2024 Jan PM MST 05 _2 .000"},
+		{"2006-01-02T15:04", "2024-03-05T07:08"},
+	}
+	for _, test := range tests {
+		if got := formatTime(newEnvironment(), tm, test.layout, Position{}); got != test.want {
+			t.Errorf("formattime(%q) = %q, want %q", test.layout, got, test.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		layout string
+		str    string
+		want   string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2024-03-01 12:30:45", "2024-03-01T12:30:45Z"},
+		{"day %d of %Y", "day 05 of 2024", "2024-01-05T00:00:00Z"},
+		{"%H:%M on Monday", "07:08 on Monday", "0000-01-01T07:08:00Z"},
+		{"%Y-%m-%d %H:%M:%S.%f", "2024-03-01 12:30:45.5", "2024-03-01T12:30:45.5Z"},
+		{"%S.%f", "01.000000123", "0000-01-01T00:00:01.000000123Z"},
+		{"%d/%b/%Y:%H:%M:%S %z", "10/Oct/2000:13:55:36 -0700", "2000-10-10T13:55:36-07:00"},
+		{"%F %T%z", "2024-03-01 12:30:45+05:30", "2024-03-01T12:30:45+05:30"},
+		{"%F %T%z", "2024-03-01 12:30:45Z", "2024-03-01T12:30:45Z"},
+		{"%F %T%z", "2024-03-01 12:30:45-23:59", "2024-03-01T12:30:45-23:59"},
+		{"%D", "03/01/69", "1969-03-01T00:00:00Z"},
+		{"%D", "3/1/68", "2068-03-01T00:00:00Z"},
+		{"%I:%M %p", "12:30 am", "0000-01-01T00:30:00Z"},
+		{"%I:%M %p", "01:30 PM", "0000-01-01T13:30:00Z"},
+		{"%A, %e %B %Y", "Friday,  1 march 2024", "2024-03-01T00:00:00Z"},
+		{"%F %T %Z", "2024-03-01 12:00:00 UTC", "2024-03-01T12:00:00Z"},
+		{"%F %T %Z", "2024-03-01 12:00:00 GMT", "2024-03-01T12:00:00Z"},
+		{"100%% %Y", "100% 2024", "2024-01-01T00:00:00Z"},
+		{"2006-01-02", "2024-03-01", "2024-03-01T00:00:00Z"},
+		{"unix", "1700000000.25", "2023-11-14T22:13:20.25Z"},
+		{"unix", "-1.5", "1969-12-31T23:59:58.5Z"},
+	}
+	for _, test := range tests {
+		if got := parseTime(newEnvironment(), test.str, test.layout, Position{}).String(); got != test.want {
+			t.Errorf("parsetime(%q) %q = %s, want %s", test.layout, test.str, got, test.want)
+		}
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	tests := []struct {
+		layout string
+		str    string
+		want   ErrorCode
+	}{
+		{"%F", "2024-02-30", E_CONVERSION},
+		{"%F", "2024-13-01", E_CONVERSION},
+		{"%T", "24:00:00", E_CONVERSION},
+		{"%I", "13", E_CONVERSION},
+		{"%Y", "24", E_CONVERSION},
+		{"%Y", "2024 ", E_CONVERSION},
+		{"day %d", "Day 05", E_CONVERSION},
+		{"%b", "Mat", E_CONVERSION},
+		{"%a %F", "Xyz 2024-03-01", E_CONVERSION},
+		{"%z", "+7", E_CONVERSION},
+		{"%z", "+9900", E_CONVERSION},
+		{"%z", "-2400", E_CONVERSION},
+		{"%z", "+0160", E_CONVERSION},
+		{"%p", "XM", E_CONVERSION},
+		{"%F %T %Z", "2024-03-01 12:00:00 XYZ", E_CONVERSION},
+		{"%Z", "", E_CONVERSION},
+		{"%Q", "1", E_BUILTIN},
+		{"%Y%", "2024", E_BUILTIN},
+		{"unix", "1.2.3", E_CONVERSION},
+		{"unix", "1.", E_CONVERSION},
+		{"unix", ".5", E_CONVERSION},
+		{"unix", "1.0000000001", E_CONVERSION},
+	}
+	for _, test := range tests {
+		code := "parsetime('" + test.layout + "') '" + test.str + "'"
+		_, err := NewEngine().Eval(code, "")
+		if errs := Errors(err); len(errs) != 1 || errs[0].Code() != test.want {
+			t.Errorf("%q returned %v, want an error with code %v", code, err, test.want)
+		}
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	layouts := []string{"%F %T.%f %z", "%a %d %b %Y %I:%M:%S %p", "%A %e %B %y %H:%M", "at %H:%M on %d.%m.%Y"}
+	tm := time.Date(2023, time.December, 9, 18, 4, 5, 250000000, time.UTC)
+	for _, layout := range layouts {
+		str := formatTime(newEnvironment(), tm, layout, Position{})
+		parsed := parseTime(newEnvironment(), str, layout, Position{}).String()
+		again := formatTime(newEnvironment(), toTime(StringValue{parsed}, Position{}), layout, Position{})
+		if again != str {
+			t.Errorf("layout %q: %q was read as %s, which is written as %q", layout, str, parsed, again)
+		}
+	}
+}
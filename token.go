@@ -1,8 +1,9 @@
-package main
+package trex
 
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 type RuneType int
@@ -50,6 +51,43 @@ type Position struct {
 	line  int
 	start int
 	end   int
+	src   *Source
+}
+
+// Line returns the line (starting at 1) the position is in.
+func (pos Position) Line() int {
+	return pos.line
+}
+
+// Start returns the column the position starts at.
+func (pos Position) Start() int {
+	return pos.start
+}
+
+// End returns the column right after the end of the position.
+func (pos Position) End() int {
+	return pos.end
+}
+
+// Source returns the code the position points into, or nil if it is unknown.
+func (pos Position) Source() *Source {
+	return pos.src
+}
+
+// Source is a piece of Trex code, along with the name of the file it was read from.
+// Name is empty for code that did not come from a file.
+type Source struct {
+	Name string
+	Code string
+}
+
+// Line returns the n-th line (starting at 1) of the source, or "" if there is no such line.
+func (src *Source) Line(n int) string {
+	lines := strings.Split(src.Code, "\n")
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
 }
 
 func showToken(tok Token) {
@@ -1,7 +1,10 @@
-package main
+package trex
 
 type TokenQueue struct {
 	tokens []Token
+	// errors holds the errors found while lexing and parsing the tokens which
+	// didn't stop the rest of the code from being lexed and parsed.
+	errors []myErr
 }
 
 func (manager *TokenQueue) size() int {
@@ -38,28 +41,28 @@ func (manager *TokenQueue) next() Token {
 
 func (manager *TokenQueue) peek() Token {
 	if len(manager.tokens) == 0 {
-		return Token{TT_UNKNOWN, "", Position{0, 0, 0}}
+		return Token{TT_UNKNOWN, "", Position{}}
 	}
 	return manager.tokens[0]
 }
 
 func (manager *TokenQueue) peekBack() Token {
 	if len(manager.tokens) == 0 {
-		return Token{TT_UNKNOWN, "", Position{0, 0, 0}}
+		return Token{TT_UNKNOWN, "", Position{}}
 	}
 	return manager.tokens[len(manager.tokens)-1]
 }
 
 func (manager *TokenQueue) peekBeforeBack() Token {
 	if len(manager.tokens) <= 1 {
-		return Token{TT_UNKNOWN, "", Position{0, 0, 0}}
+		return Token{TT_UNKNOWN, "", Position{}}
 	}
 	return manager.tokens[len(manager.tokens)-2]
 }
 
 func (manager *TokenQueue) popBack() Token {
 	if len(manager.tokens) == 0 {
-		return Token{TT_UNKNOWN, "", Position{0, 0, 0}}
+		return Token{TT_UNKNOWN, "", Position{}}
 	}
 	defer func() { manager.tokens = manager.tokens[:len(manager.tokens)-1] }()
 	return manager.peekBack()
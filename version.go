@@ -1,3 +1,4 @@
-package main
+package trex
 
-const version = "0.4.4"
+// Version is the version of the Trex language and its interpreter.
+const Version = "0.4.4"